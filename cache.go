@@ -0,0 +1,294 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package base
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default largest response body a ResponseCache will keep.
+const DefaultCacheMaxBodyBytes = 1024 * 1024
+
+// An in-memory cache of GET responses, shared by the HTTPRequests that have
+// it as their Cache.  Entries are kept for TTL, after which they are
+// revalidated with the server (If-None-Match/If-Modified-Since) if the
+// response had an ETag or Last-Modified, so an unchanged resource costs a
+// 304 rather than the whole body.  At most MaxEntries are kept, the least
+// recently used being evicted first.
+//
+// Entries are keyed by method, URL and credentials (Authorization,
+// X-Auth-Token and Cookie headers), so callers with different credentials
+// never see each other's responses.  Only 200 responses are cached, and not
+// those marked Cache-Control: no-store; those marked no-cache are always
+// revalidated.  Nor are streams: event streams, responses of unknown length,
+// and those read with DoHTTPActionStream().  A successful non-GET request to
+// a URL drops the entries for that URL.
+//
+// Callers see cached and revalidated responses just as if they came from
+// the server, with status 200.
+//
+//  cache := base.NewResponseCache(1000, time.Minute)
+//  ...
+//  request := base.NewHTTPRequest(url)
+//  request.Cache = cache
+//  body, err := request.DoHTTPAction()
+type ResponseCache struct {
+	MaxEntries   int           // 0 for no limit
+	TTL          time.Duration // How long an entry is used without revalidating
+	MaxBodyBytes int64         // Larger responses aren't cached
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // Of *cacheEntry, most recently used first
+	stats   CacheStats
+}
+
+// Counts of what a ResponseCache has done.
+type CacheStats struct {
+	Hits        int64 // Requests answered from the cache
+	Revalidated int64 // Requests answered from the cache after a 304
+	Misses      int64 // Requests that had to fetch the whole response
+	Evictions   int64 // Entries dropped to make room
+	Entries     int   // Current number of entries
+}
+
+type cacheEntry struct {
+	key          string
+	url          string
+	header       http.Header
+	body         []byte
+	expires      time.Time
+	etag         string
+	lastModified string
+}
+
+// Create a ResponseCache holding at most 'maxEntries' (0 for no limit)
+// responses for 'ttl' each.
+func NewResponseCache(maxEntries int, ttl time.Duration) *ResponseCache {
+	return &ResponseCache{
+		MaxEntries:   maxEntries,
+		TTL:          ttl,
+		MaxBodyBytes: DefaultCacheMaxBodyBytes,
+		entries:      map[string]*list.Element{},
+		lru:          list.New(),
+	}
+}
+
+// Returns the cache's statistics so far.
+func (c *ResponseCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	if c.lru != nil {
+		stats.Entries = c.lru.Len()
+	}
+	return stats
+}
+
+// Drop all entries.  Statistics are kept.
+func (c *ResponseCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[string]*list.Element{}
+	c.lru = list.New()
+}
+
+// Drop the entries for 'url', for all methods and credentials.
+func (c *ResponseCache) Invalidate(url string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, elem := range c.entries {
+		if elem.Value.(*cacheEntry).url == url {
+			c.lru.Remove(elem)
+			delete(c.entries, key)
+		}
+	}
+}
+
+// Returns the cache key for 'req'.
+func responseCacheKey(req *http.Request) string {
+	h := sha256.New()
+	for _, name := range []string{"Authorization", "X-Auth-Token", "Cookie"} {
+		for _, val := range req.Header.Values(name) {
+			io.WriteString(h, name+": "+val+"\n")
+		}
+	}
+	return req.Method + " " + req.URL.String() + " " + hex.EncodeToString(h.Sum(nil))
+}
+
+// Returns the entry for 'key', if any, and whether it is fresh enough to
+// use as-is.  Counts a hit if it is.
+func (c *ResponseCache) lookup(key string) (*cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	entry := elem.Value.(*cacheEntry)
+	if time.Now().Before(entry.expires) {
+		c.stats.Hits++
+		return entry, true
+	}
+	if entry.etag == "" && entry.lastModified == "" {
+		// Nothing to revalidate with.
+		return nil, false
+	}
+	return entry, false
+}
+
+// Set the conditional headers to revalidate 'entry', unless the caller
+// set their own.
+func (entry *cacheEntry) setConditions(h http.Header) {
+	if h.Get("If-None-Match") != "" || h.Get("If-Modified-Since") != "" {
+		return
+	}
+	if entry.etag != "" {
+		h.Set("If-None-Match", entry.etag)
+	}
+	if entry.lastModified != "" {
+		h.Set("If-Modified-Since", entry.lastModified)
+	}
+}
+
+// Record that the entry for 'key' was found unchanged by the server, which
+// sent 'header' with its 304.
+func (c *ResponseCache) revalidated(key string, header http.Header) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats.Revalidated++
+	elem, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	entry := elem.Value.(*cacheEntry)
+	entry.expires = c.expiry(header)
+	if etag := header.Get("ETag"); etag != "" {
+		entry.etag = etag
+	}
+}
+
+func (c *ResponseCache) miss() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats.Misses++
+}
+
+// Returns true if a response with 'header' may be stored.  An event stream
+// never ends, so is never stored.
+func cacheable(header http.Header) bool {
+	if mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type")); mediaType == "text/event-stream" {
+		return false
+	}
+	return !strings.Contains(strings.ToLower(header.Get("Cache-Control")), "no-store")
+}
+
+// When an entry stored or revalidated now, with response 'header', needs
+// revalidating.
+func (c *ResponseCache) expiry(header http.Header) time.Time {
+	if strings.Contains(strings.ToLower(header.Get("Cache-Control")), "no-cache") {
+		return time.Now()
+	}
+	return time.Now().Add(c.TTL)
+}
+
+func (c *ResponseCache) store(key, url string, header http.Header, body []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[string]*list.Element{}
+		c.lru = list.New()
+	}
+	entry := &cacheEntry{
+		key:          key,
+		url:          url,
+		header:       header.Clone(),
+		body:         body,
+		expires:      c.expiry(header),
+		etag:         header.Get("ETag"),
+		lastModified: header.Get("Last-Modified"),
+	}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.MaxEntries > 0 && c.lru.Len() > c.MaxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+		c.stats.Evictions++
+	}
+}
+
+// A response for the caller made from the cached entry.
+func (entry *cacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        entry.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(entry.body)),
+		ContentLength: int64(len(entry.body)),
+		Request:       req,
+	}
+}
+
+// Read (up to the size limit) and cache the body of 'resp', a fresh 200
+// response to the request for 'url' with 'key'.  The body is replaced with one that
+// reads the same data.  Returns an error only if reading fails.
+func (c *ResponseCache) storeResponse(key, url string, resp *http.Response) error {
+	limit := c.MaxBodyBytes
+	if limit <= 0 {
+		limit = DefaultCacheMaxBodyBytes
+	}
+	if !cacheable(resp.Header) || resp.ContentLength > limit {
+		return nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		resp.Body.Close()
+		return err
+	}
+	if int64(len(body)) > limit {
+		resp.Body = &readCloser{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return nil
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	c.store(key, url, resp.Header, body)
+	return nil
+}
@@ -0,0 +1,296 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package base
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Serves a resource with an ETag that changes when it is PATCHed, and
+// counts requests.
+type etagServer struct {
+	mu       sync.Mutex
+	version  int
+	requests int
+	notMod   int
+	header   http.Header
+}
+
+func (s *etagServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	for k, v := range s.header {
+		w.Header()[k] = v
+	}
+	etag := fmt.Sprintf(`"v%d"`, s.version)
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			s.notMod++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fmt.Fprintf(w, `{"version":%d,"user":"%s"}`, s.version, r.Header.Get("Authorization"))
+	case http.MethodPatch:
+		if m := r.Header.Get("If-Match"); m != "" && m != etag {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		s.version++
+	}
+}
+
+func cachedGet(t *testing.T, url string, cache *ResponseCache, auth *Auth) string {
+	request := NewHTTPRequest(url)
+	request.Cache = cache
+	request.Auth = auth
+	body, err := request.DoHTTPAction()
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	return string(body)
+}
+
+func TestResponseCache(t *testing.T) {
+	s := &etagServer{}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	cache := NewResponseCache(10, time.Hour)
+
+	first := cachedGet(t, srv.URL, cache, nil)
+	second := cachedGet(t, srv.URL, cache, nil)
+	if first != second || s.requests != 1 {
+		t.Errorf("Expected second GET from cache: %q, %q, %d requests", first, second, s.requests)
+	}
+
+	// Different credentials, different entry.
+	other := cachedGet(t, srv.URL, cache, &Auth{Username: "u", Password: "p"})
+	if other == first || s.requests != 2 {
+		t.Errorf("Cache shared between credentials: %q, %d requests", other, s.requests)
+	}
+
+	// A PATCH drops the entries for the URL.
+	request := NewHTTPRequest(srv.URL)
+	request.Method = http.MethodPatch
+	request.Cache = cache
+	if _, err := request.DoHTTPAction(); err != nil {
+		t.Fatalf("PATCH failed: %v", err)
+	}
+	if body := cachedGet(t, srv.URL, cache, nil); !strings.Contains(body, `"version":1`) {
+		t.Errorf("Expected fresh body after PATCH, got %q", body)
+	}
+
+	stats := cache.Stats()
+	if stats.Hits != 1 || stats.Misses != 3 || stats.Entries != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	cache.Purge()
+	if cache.Stats().Entries != 0 {
+		t.Errorf("Entries left after Purge()")
+	}
+}
+
+func TestResponseCacheRevalidate(t *testing.T) {
+	s := &etagServer{}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	cache := NewResponseCache(10, 0)
+
+	first := cachedGet(t, srv.URL, cache, nil)
+	second := cachedGet(t, srv.URL, cache, nil)
+	if first != second || s.requests != 2 || s.notMod != 1 {
+		t.Errorf("Expected revalidation: %q, %q, %d requests, %d 304s", first, second, s.requests, s.notMod)
+	}
+	s.mu.Lock()
+	s.version++
+	s.mu.Unlock()
+	if third := cachedGet(t, srv.URL, cache, nil); third == first {
+		t.Errorf("Stale body returned after change")
+	}
+	stats := cache.Stats()
+	if stats.Revalidated != 1 || stats.Misses != 2 || stats.Hits != 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestResponseCacheNoStoreAndEviction(t *testing.T) {
+	s := &etagServer{header: http.Header{"Cache-Control": {"no-store"}}}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	cache := NewResponseCache(2, time.Hour)
+
+	cachedGet(t, srv.URL, cache, nil)
+	cachedGet(t, srv.URL, cache, nil)
+	if s.requests != 2 || cache.Stats().Entries != 0 {
+		t.Errorf("no-store response cached: %d requests, %+v", s.requests, cache.Stats())
+	}
+
+	s.mu.Lock()
+	s.header = nil
+	s.mu.Unlock()
+	for i := 0; i < 3; i++ {
+		cachedGet(t, fmt.Sprintf("%s/%d", srv.URL, i), cache, nil)
+	}
+	stats := cache.Stats()
+	if stats.Entries != 2 || stats.Evictions != 1 {
+		t.Errorf("Unexpected stats after eviction: %+v", stats)
+	}
+	// The oldest entry was the one evicted.
+	before := s.requests
+	cachedGet(t, srv.URL+"/2", cache, nil)
+	cachedGet(t, srv.URL+"/0", cache, nil)
+	if s.requests != before+1 {
+		t.Errorf("Expected only /0 to be fetched again, got %d requests", s.requests-before)
+	}
+}
+
+func TestResponseCacheLargeBody(t *testing.T) {
+	big := strings.Repeat("x", 100)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Compressed, so only found to be too large once read.
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		gz.Write([]byte(big))
+		gz.Close()
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(buf.Bytes())
+	}))
+	defer srv.Close()
+	cache := NewResponseCache(10, time.Hour)
+	cache.MaxBodyBytes = 10
+
+	if body := cachedGet(t, srv.URL, cache, nil); body != big {
+		t.Errorf("Large body mangled: %q", body)
+	}
+	if cache.Stats().Entries != 0 {
+		t.Errorf("Large body cached")
+	}
+}
+
+// Streams are handed over as they come, not read ahead to be cached.
+func TestResponseCacheStreams(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/events" {
+			w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+		}
+		w.Write([]byte("data: 1\n\n"))
+		if r.URL.Path != "/whole" {
+			w.(http.Flusher).Flush() // No Content-Length
+			<-r.Context().Done()
+		}
+	}))
+	defer srv.Close()
+	cache := NewResponseCache(10, time.Hour)
+
+	for _, path := range []string{"/events", "/chunked"} {
+		request := NewHTTPRequest(srv.URL + path)
+		request.Cache = cache
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		request.Context = ctx
+		resp, err := request.DoHTTPResponse()
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		line := make([]byte, 7)
+		if _, err := io.ReadFull(resp.Body, line); err != nil || string(line) != "data: 1" {
+			t.Errorf("Unexpected start of %s: %q, %v", path, line, err)
+		}
+		cancel()
+		resp.Body.Close()
+	}
+
+	// A whole response read as a stream isn't stored either.
+	request := NewHTTPRequest(srv.URL + "/whole")
+	request.Cache = cache
+	body, err := request.DoHTTPActionStream()
+	if err != nil {
+		t.Fatalf("GET /whole failed: %v", err)
+	}
+	io.Copy(io.Discard, body)
+	body.Close()
+	if cache.Stats().Entries != 0 {
+		t.Errorf("Streamed responses cached: %+v", cache.Stats())
+	}
+}
+
+// Responses from the cache are limited and logged like any other.
+func TestResponseCacheHitLimitAndLog(t *testing.T) {
+	s := &etagServer{}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	cache := NewResponseCache(10, time.Hour)
+	body := cachedGet(t, srv.URL, cache, nil)
+
+	var buf bytes.Buffer
+	request := NewHTTPRequest(srv.URL)
+	request.Cache = cache
+	request.Logger = NewHTTPLogger(slog.New(slog.NewJSONHandler(&buf,
+		&slog.HandlerOptions{Level: slog.LevelDebug})))
+	if got, err := request.DoHTTPAction(); err != nil || string(got) != body {
+		t.Fatalf("Cached GET failed: %q, %v", got, err)
+	}
+	if entries := logEntries(t, &buf); len(entries) != 2 || s.requests != 1 {
+		t.Errorf("Expected cache hit with 2 log entries, got %d (%d requests)",
+			len(entries), s.requests)
+	}
+
+	request.MaxResponseBytes = int64(len(body) - 1)
+	if _, err := request.DoHTTPAction(); !errors.Is(err, ErrHTTPResponseTooLarge) {
+		t.Errorf("Expected ErrHTTPResponseTooLarge from cache, got %v", err)
+	}
+	if s.requests != 1 {
+		t.Errorf("Expected cache hit, got %d requests", s.requests)
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	s := &etagServer{}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	request := NewHTTPRequest(srv.URL)
+	request.Method = http.MethodPatch
+	request.IfMatch = `"v0"`
+	if _, err := request.DoHTTPAction(); err != nil {
+		t.Fatalf("PATCH with current ETag failed: %v", err)
+	}
+	_, err := request.DoHTTPAction()
+	if statusErr, ok := err.(*HTTPStatusError); !ok || statusErr.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("Expected 412 for stale ETag, got %v", err)
+	}
+}
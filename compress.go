@@ -0,0 +1,342 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package base
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////////////////////
+// Client side
+////////////////////////////////////////////////////////////////////////////
+
+// Accept-Encoding sent by HTTPRequests unless the caller sets their own.
+const acceptEncoding = "gzip, deflate"
+
+// Gzip 'data', for a request body.
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// A RequestBody that is 'body' gzipped on the fly.  Its length is unknown.
+func gzipBody(body *RequestBody) *RequestBody {
+	return &RequestBody{
+		Open: func() (io.Reader, error) {
+			r, err := body.Open()
+			if err != nil {
+				return nil, err
+			}
+			pr, pw := io.Pipe()
+			go func() {
+				gz := gzip.NewWriter(pw)
+				_, err := io.Copy(gz, r)
+				if err == nil {
+					err = gz.Close()
+				}
+				if c, ok := r.(io.Closer); ok {
+					c.Close()
+				}
+				pw.CloseWithError(err)
+			}()
+			return pr, nil
+		},
+		Length:    -1,
+		exclusive: body.exclusive,
+	}
+}
+
+// Returns the request body to send, gzipped if the request asks for it
+// (see CompressMinBytes), and whether it was.
+func (request *HTTPRequest) compressedBody(body *RequestBody) (*RequestBody, []byte, bool, error) {
+	min := request.CompressMinBytes
+	if min <= 0 {
+		return body, request.Payload, false, nil
+	}
+	if body != nil {
+		if body.Length >= 0 && body.Length < min {
+			return body, nil, false, nil
+		}
+		return gzipBody(body), nil, true, nil
+	}
+	if int64(len(request.Payload)) < min {
+		return nil, request.Payload, false, nil
+	}
+	payload, err := gzipBytes(request.Payload)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("unable to compress request body: %w", err)
+	}
+	return nil, payload, true, nil
+}
+
+// Replace the body of 'resp' with a decompressing one if it has a gzip or
+// deflate Content-Encoding.  As with net/http's own decompression, the
+// Content-Encoding and Content-Length headers are then removed and
+// resp.Uncompressed is set.
+func decompressResponse(resp *http.Response) error {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	var body io.Reader
+	switch encoding {
+	case "gzip", "x-gzip":
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("unable to decompress response body: %w", err)
+		}
+		if err == io.EOF {
+			// Empty body, e.g. for a HEAD request.
+			body = bytes.NewReader(nil)
+		} else {
+			body = gz
+		}
+	case "deflate":
+		body = newDeflateReader(resp.Body)
+	default:
+		return nil
+	}
+	resp.Body = &readCloser{Reader: body, Closer: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+// "deflate" is meant to be zlib-wrapped (RFC 9110), but some servers send
+// raw deflate, so look before deciding.
+func newDeflateReader(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	hdr, _ := br.Peek(2)
+	if len(hdr) == 2 && hdr[0]&0x0f == 8 && (uint16(hdr[0])<<8|uint16(hdr[1]))%31 == 0 {
+		if zr, err := zlib.NewReader(br); err == nil {
+			return zr
+		}
+	}
+	return flate.NewReader(br)
+}
+
+////////////////////////////////////////////////////////////////////////////
+// Server side
+////////////////////////////////////////////////////////////////////////////
+
+// Compress responses with gzip when the client accepts it and the response
+// is at least 'minBytes' long, and decompress gzip-encoded request bodies
+// (returning 415 Unsupported Media Type for other encodings).
+//
+// Responses that are already encoded, are of a type that doesn't compress
+// (images, archives) or are streamed (flushed before 'minBytes' have been
+// written) are sent as they are.
+//
+// Put this before MaxBodyMiddleware (or HMSMiddleware) in the chain, so body
+// limits apply to the decompressed request body:
+//
+//  handler = base.ChainMiddleware(handler,
+//      base.CompressionMiddleware(1024),
+//      base.HMSMiddleware(logger, maxBodyBytes))
+func CompressionMiddleware(minBytes int) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch strings.ToLower(r.Header.Get("Content-Encoding")) {
+			case "", "identity":
+			case "gzip", "x-gzip":
+				gz, err := gzip.NewReader(r.Body)
+				if err != nil {
+					SendProblemDetailsGeneric(w, http.StatusBadRequest,
+						"Unable to decompress request body: "+err.Error())
+					return
+				}
+				r.Body = &readCloser{Reader: gz, Closer: r.Body}
+				r.Header.Del("Content-Encoding")
+				r.Header.Del("Content-Length")
+				r.ContentLength = -1
+			default:
+				w.Header().Set("Accept-Encoding", "gzip")
+				SendProblemDetailsGeneric(w, http.StatusUnsupportedMediaType,
+					"Unsupported Content-Encoding "+r.Header.Get("Content-Encoding"))
+				return
+			}
+
+			w.Header().Add("Vary", "Accept-Encoding")
+			if r.Method == http.MethodHead || !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			cw := &compressWriter{ResponseWriter: w, minBytes: minBytes}
+			defer cw.finish()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// Returns true if an Accept-Encoding header value allows gzip.
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "x-gzip" && coding != "*" {
+			continue
+		}
+		q := 1.0
+		if name, val, ok := strings.Cut(strings.TrimSpace(params), "="); ok &&
+			strings.TrimSpace(name) == "q" {
+			q, _ = strconv.ParseFloat(strings.TrimSpace(val), 64)
+		}
+		return q > 0
+	}
+	return false
+}
+
+// Buffers the start of a response to decide whether to compress it.
+type compressWriter struct {
+	http.ResponseWriter
+	minBytes    int
+	status      int
+	wroteHeader bool // WriteHeader() called by the handler
+	decided     bool // Header sent on, compressing or not
+	buf         []byte
+	gz          *gzip.Writer
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.wroteHeader {
+		return
+	}
+	cw.status = status
+	cw.wroteHeader = true
+	// Informational responses go straight through.
+	if status < 200 {
+		cw.ResponseWriter.WriteHeader(status)
+		cw.wroteHeader = false
+		return
+	}
+	if status == http.StatusNoContent || status == http.StatusNotModified {
+		cw.decide(false)
+	}
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if !cw.decided {
+		cw.buf = append(cw.buf, b...)
+		if len(cw.buf) < cw.minBytes {
+			return len(b), nil
+		}
+		if err := cw.decide(cw.compressible()); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if cw.gz != nil {
+		return cw.gz.Write(b)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// Returns true if the response can usefully be compressed.
+func (cw *compressWriter) compressible() bool {
+	h := cw.Header()
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+	ct := strings.ToLower(h.Get("Content-Type"))
+	for _, skip := range []string{"image/", "video/", "audio/", "zip", "compressed", "gzip"} {
+		if strings.Contains(ct, skip) {
+			return false
+		}
+	}
+	return true
+}
+
+// Send the header on, and whatever is buffered, compressed or not.
+func (cw *compressWriter) decide(compress bool) error {
+	cw.decided = true
+	if compress {
+		cw.Header().Set("Content-Encoding", "gzip")
+		cw.Header().Del("Content-Length")
+		cw.gz = gzip.NewWriter(cw.ResponseWriter)
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if cw.gz != nil {
+		_, err = cw.gz.Write(buf)
+	} else {
+		_, err = cw.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// Let http.ResponseController get at the original.
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// Flushing before the response is big enough to compress means it is being
+// streamed, so it is sent uncompressed.
+func (cw *compressWriter) Flush() {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if !cw.decided {
+		cw.decide(false)
+	}
+	if cw.gz != nil {
+		cw.gz.Flush()
+	}
+	http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Called when the handler is done.
+func (cw *compressWriter) finish() {
+	if !cw.decided {
+		if !cw.wroteHeader {
+			// Nothing written at all; let net/http send its default.
+			return
+		}
+		cw.decide(false)
+	}
+	if cw.gz != nil {
+		cw.gz.Close()
+	}
+}
@@ -0,0 +1,259 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package base
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func compressWith(t *testing.T, encoding string, data []byte) []byte {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "zlib":
+		w = zlib.NewWriter(&buf)
+	case "flate":
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	}
+	w.Write(data)
+	w.Close()
+	return buf.Bytes()
+}
+
+func TestHTTPRequestDecompression(t *testing.T) {
+	data := []byte(strings.Repeat(`{"ID":"x3000c0s1b0n0"}`, 100))
+	tests := []struct {
+		format   string
+		encoding string
+	}{
+		{"gzip", "gzip"},
+		{"zlib", "deflate"},
+		{"flate", "deflate"},
+	}
+	for _, tt := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Accept-Encoding") != "gzip, deflate" {
+				t.Errorf("Unexpected Accept-Encoding %q", r.Header.Get("Accept-Encoding"))
+			}
+			w.Header().Set("Content-Encoding", tt.encoding)
+			w.Write(compressWith(t, tt.format, data))
+		}))
+		request := NewHTTPRequest(srv.URL)
+		resp, err := request.DoHTTPResponse()
+		if err != nil {
+			t.Fatalf("%s: request failed: %v", tt.format, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if !bytes.Equal(body, data) || resp.Header.Get("Content-Encoding") != "" || !resp.Uncompressed {
+			t.Errorf("%s: response not decompressed: %q", tt.format, body[:min(len(body), 20)])
+		}
+		srv.Close()
+	}
+}
+
+func TestHTTPRequestDecompressionLimit(t *testing.T) {
+	// Highly compressible, so the compressed body is well under the limit.
+	bomb := compressWith(t, "gzip", make([]byte, 1024*1024))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(bomb)
+	}))
+	defer srv.Close()
+
+	request := NewHTTPRequest(srv.URL)
+	request.MaxResponseBytes = 64 * 1024
+	if _, err := request.DoHTTPAction(); !errors.Is(err, ErrHTTPResponseTooLarge) {
+		t.Errorf("Expected ErrHTTPResponseTooLarge, got %v", err)
+	}
+
+	// Callers that ask for an encoding themselves get it as it is.
+	request.MaxResponseBytes = 0
+	request.Header = http.Header{"Accept-Encoding": {"gzip"}}
+	body, err := request.DoHTTPAction()
+	if err != nil || !bytes.Equal(body, bomb) {
+		t.Errorf("Expected compressed body as sent, got %d bytes, %v", len(body), err)
+	}
+}
+
+func TestHTTPRequestCompression(t *testing.T) {
+	var encodings, bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings = append(encodings, r.Header.Get("Content-Encoding"))
+		var body io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			gz, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Errorf("Bad gzip body: %v", err)
+				return
+			}
+			body = gz
+		}
+		b, _ := io.ReadAll(body)
+		bodies = append(bodies, string(b))
+	}))
+	defer srv.Close()
+
+	large := strings.Repeat("a", 2048)
+	request := NewHTTPRequest(srv.URL)
+	request.Method = http.MethodPost
+	request.CompressMinBytes = 1024
+	request.Payload = []byte("small")
+	request.DoHTTPAction()
+	request.Payload = []byte(large)
+	request.DoHTTPAction()
+	request.Payload = nil
+	request.Body = &RequestBody{
+		Open:   func() (io.Reader, error) { return strings.NewReader(large), nil },
+		Length: -1,
+	}
+	request.DoHTTPAction()
+
+	if strings.Join(encodings, ",") != ",gzip,gzip" {
+		t.Errorf("Unexpected request encodings %q", encodings)
+	}
+	if len(bodies) != 3 || bodies[0] != "small" || bodies[1] != large || bodies[2] != large {
+		t.Errorf("Request bodies mangled")
+	}
+}
+
+func compressionHandler(body string, contentType string, flush bool) http.Handler {
+	return CompressionMiddleware(100)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+		for _, line := range strings.SplitAfter(body, "\n") {
+			io.WriteString(w, line)
+			if flush {
+				w.(http.Flusher).Flush()
+			}
+		}
+	}))
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+	large := strings.Repeat("line of text\n", 50)
+	tests := []struct {
+		name        string
+		accept      string
+		body        string
+		contentType string
+		flush       bool
+		gzipped     bool
+	}{
+		{"large", "gzip, deflate", large, "", false, true},
+		{"q", "deflate, gzip;q=0.5", large, "", false, true},
+		{"q=0", "gzip;q=0", large, "", false, false},
+		{"not accepted", "", large, "", false, false},
+		{"small", "gzip", "tiny", "", false, false},
+		{"image", "gzip", large, "image/png", false, false},
+		{"streamed", "gzip", large, "text/event-stream", true, false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept-Encoding", tt.accept)
+		}
+		rec := httptest.NewRecorder()
+		compressionHandler(tt.body, tt.contentType, tt.flush).ServeHTTP(rec, req)
+
+		body := rec.Body.Bytes()
+		gzipped := rec.Header().Get("Content-Encoding") == "gzip"
+		if gzipped {
+			gz, err := gzip.NewReader(rec.Body)
+			if err != nil {
+				t.Fatalf("%s: bad gzip response: %v", tt.name, err)
+			}
+			body, _ = io.ReadAll(gz)
+		}
+		if gzipped != tt.gzipped || string(body) != tt.body {
+			t.Errorf("%s: gzipped %v, body ok %v", tt.name, gzipped, string(body) == tt.body)
+		}
+		if rec.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("%s: no Vary header", tt.name)
+		}
+	}
+}
+
+func TestCompressionMiddlewareRequests(t *testing.T) {
+	var got string
+	handler := CompressionMiddleware(100)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got = string(b)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(compressWith(t, "gzip", []byte("hello"))))
+	req.Header.Set("Content-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent || got != "hello" {
+		t.Errorf("gzip request not decompressed: %d, %q", rec.Code, got)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello"))
+	req.Header.Set("Content-Encoding", "br")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("Expected 415 for br request, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("not gzip"))
+	req.Header.Set("Content-Encoding", "gzip")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for bad gzip request, got %d", rec.Code)
+	}
+}
+
+// HTTPRequests and CompressionMiddleware understand each other.
+func TestCompressionEndToEnd(t *testing.T) {
+	large := strings.Repeat(`{"State":"Ready"}`, 200)
+	srv := httptest.NewServer(CompressionMiddleware(100)(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			io.Copy(w, r.Body)
+		})))
+	defer srv.Close()
+
+	request := NewHTTPRequest(srv.URL)
+	request.Method = http.MethodPost
+	request.Payload = []byte(large)
+	request.CompressMinBytes = 100
+	body, err := request.DoHTTPAction()
+	if err != nil || string(body) != large {
+		t.Errorf("Round trip failed: %v", err)
+	}
+}
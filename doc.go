@@ -1,6 +1,6 @@
 // MIT License
 //
-// (C) Copyright [2018, 2021] Hewlett Packard Enterprise Development LP
+// (C) Copyright [2018, 2021, 2026] Hewlett Packard Enterprise Development LP
 //
 // Permission is hereby granted, free of charge, to any person obtaining a
 // copy of this software and associated documentation files (the "Software"),
@@ -28,10 +28,24 @@
 // component data that represents system components and valid values for that
 // data.
 //
-// HMS Errors and RFC 7807 ProblemDetails
+// HMS Errors and RFC 9457 ProblemDetails
 //
 // These are common methods for defining a custom HMS error type and producing
-// RFC 7807-compliant ProblemDetails payloads for reporting problems that occur
-// during HMS API calls.
+// RFC 9457 (formerly RFC 7807) compliant ProblemDetails payloads for
+// reporting problems that occur during HMS API calls, and for turning those
+// received from other services back into HMS errors.
+//
+// HTTP Clients and Servers
+//
+// HTTPRequest wraps the common case of making a JSON request to another
+// service (with retries), and HTTPClient holds the transports and TLS
+// settings those requests share, along with any per-host limits (see
+// HostLimiter) and proxy, Unix socket and dialing options (see
+// TransportOptions).  A ServiceRegistry can send requests for logical
+// services like "cray-smd" to whichever of their endpoints are healthy.
+// HTTPLogger logs the exchanges for debugging, with any credentials
+// redacted.  On the server side, Middleware such as HMSMiddleware gives all
+// HMS services the same request ID, logging, panic recovery and request
+// body handling.
 //
 package base
@@ -0,0 +1,342 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package base
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// JobType of the Jobs FanOutHTTP() runs on a WorkerPool.
+const JTYPE_HTTP_FANOUT JobType = 100
+
+// Workers used by FanOutHTTP() when not given a WorkerPool.
+const DefaultFanOutWorkers = 64
+
+// Result error of every target of FanOutHTTP() if its template's Body can't
+// be sent to several targets at once, e.g. one from NewReadSeekerBody().
+var ErrFanOutExclusiveBody = errors.New("request body can't be sent to several targets at once")
+
+// One of the targets of FanOutHTTP().
+type FanOutTarget struct {
+	ID      string // Identifies the target in results, e.g. an xname; defaults to the URL's host
+	URL     string // Full URL to send the request to
+	Payload []byte // Payload for this target, nil for the template's
+}
+
+// The outcome of the request to one target.  Err is nil only if the
+// response had the status expected by the template.  For an unexpected
+// status, StatusCode, Header and (the start of) Body are still set.
+type FanOutResult struct {
+	Target     FanOutTarget
+	StatusCode int // 0 if no response was received
+	Header     http.Header
+	Body       []byte
+	Err        error
+	Duration   time.Duration
+}
+
+// Counts of FanOutHTTP() results.
+type FanOutSummary struct {
+	Total     int
+	Succeeded int
+	Failed    int         // Not including Cancelled
+	Cancelled int         // Not attempted, or interrupted, because the fan-out was cancelled
+	ByStatus  map[int]int // Responses received, by status code
+	Duration  time.Duration
+}
+
+// Options for FanOutHTTP().
+type FanOutOptions struct {
+	Pool    *WorkerPool // Pool to run requests on, nil for a new one of Workers workers
+	Workers int         // Workers in the new pool, 0 for DefaultFanOutWorkers
+
+	// Per-host limits, in addition to any set on the template's HTTPClient.
+	Limiter *HostLimiter
+
+	// Called with each result as it comes in, e.g. to show progress.  Calls
+	// are made one at a time, from the goroutine that called FanOutHTTP().
+	OnResult func(*FanOutResult)
+
+	Logger *slog.Logger // For job logging, nil for slog.Default()
+}
+
+// Make the same request to many targets, e.g. the same Redfish call to
+// every BMC, and collect the results.  'template' is copied for each
+// target, with the target's URL (and Payload, if set) and 'ctx'.  Its Body,
+// if set, is shared by all the requests, so must open a separate reader
+// each time, as those from NewBytesBody() and NewFileBody() do; one from
+// NewReadSeekerBody() fails every target with ErrFanOutExclusiveBody.
+//
+// Requests run as Jobs on a WorkerPool, bounding how many are made at once.
+// Results are returned in the order of 'targets', and also passed to
+// opts.OnResult as they come in.  Cancelling 'ctx' cancels the whole
+// fan-out: requests not yet made are not made, those in progress are
+// interrupted, and all of them get ctx.Err() as their error.
+//
+//  targets := []base.FanOutTarget{}
+//  for _, xname := range bmcs {
+//      targets = append(targets, base.FanOutTarget{ID: xname,
+//          URL: "https://" + xname + "/redfish/v1/Systems/Node0"})
+//  }
+//  results, summary := base.FanOutHTTP(ctx, template, targets, base.FanOutOptions{})
+func FanOutHTTP(ctx context.Context, template *HTTPRequest, targets []FanOutTarget, opts FanOutOptions) ([]FanOutResult, FanOutSummary) {
+	start := time.Now()
+	if len(targets) == 0 {
+		return nil, FanOutSummary{ByStatus: map[int]int{}}
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pool := opts.Pool
+	if pool == nil {
+		workers := opts.Workers
+		if workers <= 0 {
+			workers = DefaultFanOutWorkers
+		}
+		if workers > len(targets) {
+			workers = len(targets)
+		}
+		pool = NewWorkerPool(max(workers, 1), len(targets))
+		pool.Run()
+		defer pool.Stop()
+	}
+
+	results := make([]FanOutResult, len(targets))
+	done := make(chan int, len(targets))
+	jobs := make([]*fanOutJob, len(targets))
+	for i, target := range targets {
+		if target.ID == "" {
+			if u, err := url.Parse(target.URL); err == nil {
+				target.ID = u.Host
+			}
+		}
+		jobs[i] = &fanOutJob{
+			ctx:      ctx,
+			template: template,
+			limiter:  opts.Limiter,
+			logger:   loggerOrDefault(opts.Logger),
+			target:   target,
+			result:   &results[i],
+			index:    i,
+			done:     done,
+		}
+	}
+
+	var bodyErr error
+	if template.Body != nil && template.Body.exclusive {
+		bodyErr = ErrFanOutExclusiveBody
+	}
+
+	// Queue in the background, so results can be delivered meanwhile.
+	go func() {
+		for _, job := range jobs {
+			if bodyErr != nil {
+				job.finish(FanOutResult{Err: bodyErr})
+			} else if !queueJob(ctx, pool, job) {
+				job.finish(FanOutResult{Err: ctx.Err()})
+			}
+		}
+	}()
+
+	summary := FanOutSummary{Total: len(targets), ByStatus: map[int]int{}}
+	cancelled := ctx.Done()
+	for n := 0; n < len(targets); {
+		select {
+		case i := <-done:
+			n++
+			result := &results[i]
+			switch {
+			case result.Err == nil:
+				summary.Succeeded++
+			case errors.Is(result.Err, context.Canceled) || errors.Is(result.Err, context.DeadlineExceeded):
+				summary.Cancelled++
+			default:
+				summary.Failed++
+			}
+			if result.StatusCode != 0 {
+				summary.ByStatus[result.StatusCode]++
+			}
+			if opts.OnResult != nil {
+				opts.OnResult(result)
+			}
+		case <-cancelled:
+			// Jobs still queued will not be run by the pool, so finish
+			// them here.  Those running see the cancellation themselves.
+			cancelled = nil
+			for _, job := range jobs {
+				if job.Cancel() == JSTAT_CANCELLED {
+					job.finish(FanOutResult{Err: ctx.Err()})
+				}
+			}
+		}
+	}
+	summary.Duration = time.Since(start)
+	return results, summary
+}
+
+// Queue 'job' on 'pool', waiting for room if its queue is full.  Returns
+// false if 'ctx' is cancelled first.
+func queueJob(ctx context.Context, pool *WorkerPool, job Job) bool {
+	for {
+		if ctx.Err() != nil {
+			return false
+		}
+		switch pool.Queue(job) {
+		case 0:
+			return true
+		case 1:
+			select {
+			case <-time.After(10 * time.Millisecond):
+			case <-ctx.Done():
+				return false
+			}
+		default:
+			return false
+		}
+	}
+}
+
+// Makes the request to one target of a fan-out.
+type fanOutJob struct {
+	mu     sync.Mutex
+	status JobStatus
+	err    error
+
+	ctx      context.Context
+	template *HTTPRequest
+	limiter  *HostLimiter
+	logger   *slog.Logger
+	target   FanOutTarget
+	result   *FanOutResult
+	index    int
+	done     chan<- int
+	once     sync.Once
+}
+
+func (j *fanOutJob) Log(format string, a ...interface{}) {
+	j.logger.Info(fmt.Sprintf(format, a...))
+}
+
+func (j *fanOutJob) Type() JobType {
+	return JTYPE_HTTP_FANOUT
+}
+
+func (j *fanOutJob) Run() {
+	if err := j.ctx.Err(); err != nil {
+		j.finish(FanOutResult{Err: err})
+		return
+	}
+	start := time.Now()
+	request := *j.template
+	request.Context = j.ctx
+	request.FullURL = j.target.URL
+	if j.target.Payload != nil {
+		request.Payload = j.target.Payload
+	}
+
+	if j.limiter != nil {
+		if u, err := url.Parse(request.FullURL); err == nil {
+			release, err := j.limiter.Wait(j.ctx, u.Host)
+			if err != nil {
+				j.finish(FanOutResult{Err: err})
+				return
+			}
+			defer release()
+		}
+	}
+
+	var result FanOutResult
+	resp, err := request.DoHTTPResponse()
+	if err == nil {
+		result.StatusCode = resp.StatusCode
+		result.Header = resp.Header
+		result.Body, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			err = fmt.Errorf("unable to read response body: %w", err)
+		}
+	} else {
+		var statusErr *HTTPStatusError
+		if errors.As(err, &statusErr) {
+			result.StatusCode = statusErr.StatusCode
+			result.Header = statusErr.Header
+			result.Body = statusErr.Body
+		}
+	}
+	if err != nil && j.ctx.Err() != nil {
+		err = j.ctx.Err()
+	}
+	if err != nil {
+		j.SetStatus(JSTAT_ERROR, err)
+	}
+	result.Err = err
+	result.Duration = time.Since(start)
+	j.finish(result)
+}
+
+// Record the outcome and report it, once only, whoever gets there first
+// (the job or a cancellation).
+func (j *fanOutJob) finish(result FanOutResult) {
+	j.once.Do(func() {
+		result.Target = j.target
+		*j.result = result
+		j.done <- j.index
+	})
+}
+
+func (j *fanOutJob) GetStatus() (JobStatus, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.status, j.err
+}
+
+func (j *fanOutJob) SetStatus(status JobStatus, err error) (JobStatus, error) {
+	if status >= JSTAT_MAX {
+		return j.GetStatus()
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	old := j.status
+	j.status, j.err = status, err
+	return old, nil
+}
+
+func (j *fanOutJob) Cancel() JobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.status == JSTAT_QUEUED || j.status == JSTAT_DEFAULT {
+		j.status = JSTAT_CANCELLED
+	}
+	return j.status
+}
@@ -0,0 +1,223 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package base
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Server that answers /ok/N with N, and /fail/N with 404, and records the
+// most requests it has handled at once.
+type fanOutServer struct {
+	*httptest.Server
+	inFlight, maxInFlight int32
+	delay                 time.Duration
+	block                 chan struct{}
+}
+
+func newFanOutServer(delay time.Duration) *fanOutServer {
+	s := &fanOutServer{delay: delay}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&s.inFlight, 1)
+		defer atomic.AddInt32(&s.inFlight, -1)
+		for {
+			peak := atomic.LoadInt32(&s.maxInFlight)
+			if n <= peak || atomic.CompareAndSwapInt32(&s.maxInFlight, peak, n) {
+				break
+			}
+		}
+		if s.block != nil {
+			select {
+			case <-s.block:
+			case <-r.Context().Done():
+				return
+			}
+		}
+		time.Sleep(s.delay)
+		if strings.HasPrefix(r.URL.Path, "/fail/") {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		w.Write([]byte(strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/ok/"), "/fail/")))
+	}))
+	return s
+}
+
+func fanOutTargets(base string, n int) []FanOutTarget {
+	targets := []FanOutTarget{}
+	for i := 0; i < n; i++ {
+		path := "ok"
+		if i%5 == 0 {
+			path = "fail"
+		}
+		targets = append(targets, FanOutTarget{ID: fmt.Sprintf("x%d", i),
+			URL: fmt.Sprintf("%s/%s/%d", base, path, i)})
+	}
+	return targets
+}
+
+func TestFanOutHTTP(t *testing.T) {
+	srv := newFanOutServer(5 * time.Millisecond)
+	defer srv.Close()
+
+	var streamed []string
+	targets := fanOutTargets(srv.URL, 50)
+	results, summary := FanOutHTTP(context.Background(), NewHTTPRequest(""), targets,
+		FanOutOptions{Workers: 4, OnResult: func(r *FanOutResult) {
+			streamed = append(streamed, r.Target.ID)
+		}})
+
+	if len(results) != 50 || len(streamed) != 50 {
+		t.Fatalf("Expected 50 results and 50 streamed, got %d and %d", len(results), len(streamed))
+	}
+	for i, r := range results {
+		if r.Target.ID != targets[i].ID || string(r.Body) != fmt.Sprint(i) {
+			t.Errorf("Result %d is for %s with body '%s'", i, r.Target.ID, r.Body)
+		}
+		if i%5 == 0 && (r.Err == nil || r.StatusCode != http.StatusNotFound) {
+			t.Errorf("Expected result %d to fail with 404, got %d, %v", i, r.StatusCode, r.Err)
+		} else if i%5 != 0 && (r.Err != nil || r.StatusCode != http.StatusOK) {
+			t.Errorf("Expected result %d to succeed, got %d, %v", i, r.StatusCode, r.Err)
+		}
+	}
+	if summary.Total != 50 || summary.Succeeded != 40 || summary.Failed != 10 ||
+		summary.Cancelled != 0 || summary.ByStatus[200] != 40 || summary.ByStatus[404] != 10 {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+	if srv.maxInFlight > 4 {
+		t.Errorf("Expected at most 4 requests at once, got %d", srv.maxInFlight)
+	}
+
+	// Default ID, and per-target payloads.
+	results, _ = FanOutHTTP(context.Background(), NewHTTPRequest(""),
+		[]FanOutTarget{{URL: srv.URL + "/ok/1", Payload: []byte("{}")}}, FanOutOptions{})
+	if results[0].Target.ID != strings.TrimPrefix(srv.URL, "http://") {
+		t.Errorf("Expected ID to default to the host, got '%s'", results[0].Target.ID)
+	}
+}
+
+func TestFanOutHTTPLimits(t *testing.T) {
+	srv := newFanOutServer(5 * time.Millisecond)
+	defer srv.Close()
+
+	// Per-host limits on top of the pool's.
+	limiter := NewHostLimiter(HostLimit{MaxInFlight: 2})
+	_, summary := FanOutHTTP(context.Background(), NewHTTPRequest(""),
+		fanOutTargets(srv.URL, 20), FanOutOptions{Workers: 10, Limiter: limiter})
+	if summary.Succeeded+summary.Failed != 20 {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+	if srv.maxInFlight > 2 {
+		t.Errorf("Expected at most 2 requests at once, got %d", srv.maxInFlight)
+	}
+
+	// A shared pool with a queue too small for all the targets.
+	pool := NewWorkerPool(3, 2)
+	pool.Run()
+	defer pool.Stop()
+	_, summary = FanOutHTTP(context.Background(), NewHTTPRequest(""),
+		fanOutTargets(srv.URL, 20), FanOutOptions{Pool: pool})
+	if summary.Succeeded != 16 || summary.Failed != 4 {
+		t.Errorf("Unexpected summary with a shared pool: %+v", summary)
+	}
+}
+
+func TestFanOutHTTPBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+	}))
+	defer srv.Close()
+	targets := fanOutTargets(srv.URL, 10)
+
+	// A body that opens a new reader each time is sent to every target.
+	template := NewHTTPRequest("")
+	template.Method = http.MethodPut
+	template.Body = NewBytesBody([]byte("firmware"))
+	results, summary := FanOutHTTP(context.Background(), template, targets,
+		FanOutOptions{Workers: 4})
+	for i, r := range results {
+		if r.Err != nil || string(r.Body) != "firmware" {
+			t.Errorf("Result %d: expected body 'firmware', got '%s', %v", i, r.Body, r.Err)
+		}
+	}
+
+	// One sharing a reader isn't sent at all, nor is a multipart body with
+	// such a part.
+	shared, _ := NewReadSeekerBody(strings.NewReader("firmware"))
+	mp := NewMultipartBuilder()
+	mp.AddField("Targets", "BMC")
+	mp.AddFileBody("UpdateFile", "image.bin", "application/octet-stream", shared)
+	for _, body := range []*RequestBody{shared, mp.Body()} {
+		template.Body = body
+		results, summary = FanOutHTTP(context.Background(), template, targets,
+			FanOutOptions{Workers: 4})
+		for i, r := range results {
+			if !errors.Is(r.Err, ErrFanOutExclusiveBody) || r.StatusCode != 0 {
+				t.Errorf("Result %d: expected ErrFanOutExclusiveBody, got %d, %v", i, r.StatusCode, r.Err)
+			}
+		}
+		if summary.Failed != 10 {
+			t.Errorf("Unexpected summary: %+v", summary)
+		}
+	}
+}
+
+func TestFanOutHTTPCancel(t *testing.T) {
+	srv := newFanOutServer(0)
+	srv.block = make(chan struct{})
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var once sync.Once
+	go func() {
+		// Let a few through, then cancel with the rest blocked or queued.
+		for i := 0; i < 3; i++ {
+			srv.block <- struct{}{}
+		}
+		time.Sleep(20 * time.Millisecond)
+		once.Do(cancel)
+	}()
+	start := time.Now()
+	results, summary := FanOutHTTP(ctx, NewHTTPRequest(""), fanOutTargets(srv.URL, 30),
+		FanOutOptions{Workers: 5})
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Cancelled fan-out took %v", elapsed)
+	}
+	if summary.Total != 30 || summary.Succeeded+summary.Failed != 3 || summary.Cancelled != 27 {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+	for _, r := range results {
+		if r.Target.ID == "" {
+			t.Errorf("Result with no target: %+v", r)
+		}
+	}
+}
@@ -7,6 +7,7 @@ toolchain go1.24.1
 require (
 	github.com/fsnotify/fsnotify v1.9.0
 	github.com/hashicorp/go-retryablehttp v0.7.7
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
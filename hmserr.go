@@ -1,6 +1,6 @@
 // MIT License
 //
-// (C) Copyright [2018, 2021] Hewlett Packard Enterprise Development LP
+// (C) Copyright [2018, 2021, 2026] Hewlett Packard Enterprise Development LP
 //
 // Permission is hereby granted, free of charge, to any person obtaining a
 // copy of this software and associated documentation files (the "Software"),
@@ -24,6 +24,7 @@ package base
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -31,19 +32,19 @@ import (
 
 ////////////////////////////////////////////////////////////////////////////
 //
-// RFC 7807-compliant Problem Details struct
+// RFC 9457 (formerly RFC 7807) compliant Problem Details struct
 //
 ////////////////////////////////////////////////////////////////////////////
 
 const ProblemDetailsHTTPStatusType = "about:blank"
 const ProblemDetailContentType = "application/problem+json"
 
-// RFC 7807 Problem Details
+// RFC 9457 Problem Details (RFC 9457 obsoletes RFC 7807, but is compatible
+// with it)
 //
-// These are the officially-specified fields.  The implementation
-// is allowed to add new ones, but we'll stick with these for now.
-// Almost all are optional, however, and blank fields are not encoded
-// if they are the empty string (which is fine
+// These are the officially-specified fields, followed by the extension
+// members described below.  Almost all are optional, however, and blank
+// fields are not encoded if they are the empty string (which is fine
 //
 // The only required field is Type and is expected to be a URL that
 // should describe the problem type when dereferenced.  It's not intended
@@ -79,13 +80,35 @@ const ProblemDetailContentType = "application/problem+json"
 // should match at least the intended HTTP code in the header, though this
 // is not strictly required.
 //
-// For more info, reading RFC 7807 would obviously be the authoritative source.
+// InvalidParams lists the request parameters (e.g. fields of a JSON body)
+// that failed validation, as the "invalid-params" extension member.
+//
+// Errors lists multiple occurrences of the same problem type, e.g. several
+// invalid fields of a request body, as the "errors" extension member
+// suggested by RFC 9457.  A response only ever reports one problem type:
+// if there are problems of different types, report the most relevant (see
+// MergeProblems()).
+//
+// Any other extension members, e.g. an error code, the xname involved or
+// the request ID, go in Extensions, and are marshaled (and unmarshaled) at
+// the top level of the JSON object alongside the standard members.  See
+// WithExtension(), WithInvalidParam() and WithOccurrence().
+//
+// For more info, reading RFC 9457 would obviously be the authoritative source.
+// Receivers are required to be tolerant (see UnmarshalJSON()), and Type and
+// Instance may be relative URIs (see Resolve()).  ProblemType describes
+// common types of problem.
 type ProblemDetails struct {
-	Type     string `json:"type"` // either url or "about:blank"
-	Title    string `json:"title,omitempty"`
-	Detail   string `json:"detail,omitempty"`
-	Instance string `json:"instance,omitempty"`
-	Status   int    `json:"status,omitempty"`
+	Type          string              `json:"type"` // either url or "about:blank"
+	Title         string              `json:"title,omitempty"`
+	Detail        string              `json:"detail,omitempty"`
+	Instance      string              `json:"instance,omitempty"`
+	Status        int                 `json:"status,omitempty"`
+	InvalidParams []InvalidParam      `json:"invalid-params,omitempty"`
+	Errors        []ProblemOccurrence `json:"errors,omitempty"`
+
+	// Extension members, by name.  Names of the members above are ignored.
+	Extensions map[string]interface{} `json:"-"`
 }
 
 // New full ProblemDetails, will all fields specified.
@@ -151,7 +174,9 @@ func NewProblemDetailsStatus(detail string, status int) *ProblemDetails {
 
 // Create a new ProblemDetails struct copied from p with only detail and
 // instance (optionally) updated.  If either field is the empty string, it
-// will not be updated and the parent values will be used.
+// will not be updated and the parent values will be used.  InvalidParams,
+// Errors and Extensions are copied too (though the values of Extensions are not
+// deep-copied).
 //
 // The basic idea here is to be able to define a few prototype ProblemDetails
 // with the Type and Title filled in (along with a default Detail and HTTP
@@ -192,6 +217,18 @@ func (p *ProblemDetails) NewChild(detail, instance string) *ProblemDetails {
 	} else {
 		newProb.Instance = p.Instance
 	}
+	if p.InvalidParams != nil {
+		newProb.InvalidParams = append([]InvalidParam{}, p.InvalidParams...)
+	}
+	if p.Errors != nil {
+		newProb.Errors = append([]ProblemOccurrence{}, p.Errors...)
+	}
+	if p.Extensions != nil {
+		newProb.Extensions = make(map[string]interface{}, len(p.Extensions))
+		for name, val := range p.Extensions {
+			newProb.Extensions[name] = val
+		}
+	}
 
 	return newProb
 }
@@ -313,6 +350,11 @@ const HMSErrorUnsetDefault = "no error message or class set"
 // them things that expose details about the database structure.
 //
 // Can attach custom RFC7807 response if needed.
+//
+// An HMSError can also wrap an underlying cause (see WithCause()), and
+// works with errors.Is() and errors.As(), including when it has itself
+// been wrapped, e.g. by fmt.Errorf("...: %w", err).  See Is() for how
+// HMSErrors match each other.
 type HMSError struct {
 	// Used to identify similar groups of errors to higher-level software.
 	Class string `json:"class"`
@@ -322,6 +364,14 @@ type HMSError struct {
 
 	// Optional full ProblemDetails
 	Problem *ProblemDetails `json:"problem,omitempty"`
+
+	// Optional underlying error.  It is not part of Error(), as that is
+	// often passed on to users, but can be got at with errors.Unwrap(),
+	// errors.Is() and errors.As().
+	Cause error `json:"-"`
+
+	// The HMSError this was created from with NewChild() etc., if any.
+	parent *HMSError
 }
 
 // New HMSError, with message string and optional class.
@@ -353,32 +403,95 @@ func (e *HMSError) Error() string {
 	}
 }
 
+// Returns e's Cause, so errors.Is() and errors.As() look at it too.
+func (e *HMSError) Unwrap() error {
+	return e.Cause
+}
+
+// Reports whether e matches 'target', for errors.Is().  An HMSError matches
+// another of the same Class if it is the same error, if it was created from
+// it (at any remove) with NewChild(), NewChildWithProblem() or WithCause(),
+// or if the other has no Message, so it stands for the whole Class:
+//
+//  err := ErrHMSStateInvalid.NewChild("'Bogus' is not a valid state")
+//  errors.Is(fmt.Errorf("update failed: %w", err), ErrHMSStateInvalid) // true
+//  errors.Is(err, ErrHMSNeedForce)                                     // false
+//  errors.Is(err, NewHMSError("hms", ""))                              // true
+func (e *HMSError) Is(target error) bool {
+	t, ok := target.(*HMSError)
+	if !ok || t == nil || t.Class != e.Class {
+		return false
+	}
+	if t.Message == "" {
+		return true
+	}
+	for anc := e; anc != nil; anc = anc.parent {
+		if anc == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Create a new HMSError that is a (deep) copy of e, as with
+// NewChildWithProblem(), wrapping 'cause'.  It matches e with errors.Is(),
+// as well as anything 'cause' matches.
+//
+//  if err := db.Update(comp); err != nil {
+//      return ErrHMSStateUnsupported.WithCause(err)
+//  }
+func (e *HMSError) WithCause(cause error) *HMSError {
+	newErr := e.NewChildWithProblem("", "")
+	newErr.Cause = cause
+	return newErr
+}
+
 // See if an error (some thing implementing Error() interface is an HMSError
 //
-// Returns 'true' if err is HMSError
+// Returns 'true' if err is HMSError, or wraps one
 // Returns 'false' if err is something else that implements Error()
 func IsHMSError(err error) bool {
-	_, ok := err.(*HMSError)
-	if !ok {
-		return false
-	} else {
-		return true
-	}
+	_, ok := GetHMSError(err)
+	return ok
 }
 
 // Test and retrieve HMSError info, if error is in fact of that type.
 //
 // If bool is 'true', HMSError will be a non-nil HMSError with the expected
-// extended HMSError fields.
+// extended HMSError fields.  If err wraps more than one, this is the
+// outermost.
 //
 // If bool is 'false', err is not an HMSError and *HMSError will be nil
 func GetHMSError(err error) (*HMSError, bool) {
-	hmserr, ok := err.(*HMSError)
-	if !ok {
+	var hmserr *HMSError
+	if !errors.As(err, &hmserr) || hmserr == nil {
 		return nil, false
-	} else {
-		return hmserr, true
 	}
+	return hmserr, true
+}
+
+// Returns true if 'fn' returns true for any HMSError in err's chain
+// (including all branches of any errors.Join()).
+func anyHMSError(err error, fn func(*HMSError) bool) bool {
+	for err != nil {
+		if hmserr, ok := err.(*HMSError); ok && hmserr != nil && fn(hmserr) {
+			return true
+		}
+		switch u := err.(type) {
+		case interface{ Unwrap() error }:
+			err = u.Unwrap()
+		case interface{ Unwrap() []error }:
+			for _, branch := range u.Unwrap() {
+				if anyHMSError(branch, fn) {
+					return true
+				}
+			}
+			return false
+		default:
+			return false
+		}
+	}
+	return false
 }
 
 // Return true if 'class' exactly matches Class field for HMSError
@@ -398,23 +511,21 @@ func (e *HMSError) IsClassIgnCase(class string) bool {
 }
 
 // Returns false if 'err' is not an HMSError, or, if it is, if 'class' doesn't
-// match the HMSError's Class field.
+// match the HMSError's Class field.  If 'err' wraps HMSErrors, true is
+// returned if any of them match.
 func IsHMSErrorClass(err error, class string) bool {
-	hmserr, ok := GetHMSError(err)
-	if !ok {
-		return false
-	}
-	return hmserr.IsClass(class)
+	return anyHMSError(err, func(hmserr *HMSError) bool {
+		return hmserr.IsClass(class)
+	})
 }
 
 // Returns false if 'err' is not an HMSError, or, if it is, if 'class' doesn't
-// match the HMSError's Class field (case insensitive).
+// match the HMSError's Class field (case insensitive).  If 'err' wraps
+// HMSErrors, true is returned if any of them match.
 func IsHMSErrorClassIgnCase(err error, class string) bool {
-	hmserr, ok := GetHMSError(err)
-	if !ok {
-		return false
-	}
-	return hmserr.IsClassIgnCase(class)
+	return anyHMSError(err, func(hmserr *HMSError) bool {
+		return hmserr.IsClassIgnCase(class)
+	})
 }
 
 // Add an ProblemDetails to be associated with e
@@ -448,6 +559,8 @@ func (e *HMSError) NewChild(msg string) *HMSError {
 	} else {
 		newErr.Message = e.Message
 	}
+	newErr.Cause = e.Cause
+	newErr.parent = e
 	return newErr
 }
 
@@ -477,5 +590,7 @@ func (e *HMSError) NewChildWithProblem(msg, instance string) *HMSError {
 	if e.Problem != nil {
 		newErr.Problem = e.Problem.NewChild(msg, instance)
 	}
+	newErr.Cause = e.Cause
+	newErr.parent = e
 	return newErr
 }
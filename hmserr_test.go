@@ -1,6 +1,6 @@
 // MIT License
 //
-// (C) Copyright [2021] Hewlett Packard Enterprise Development LP
+// (C) Copyright [2021, 2026] Hewlett Packard Enterprise Development LP
 //
 // Permission is hereby granted, free of charge, to any person obtaining a
 // copy of this software and associated documentation files (the "Software"),
@@ -24,6 +24,7 @@ package base
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -981,3 +982,47 @@ func TestNewChildWithProblem(t *testing.T) {
 		t.Logf("Testcase 5b: Pass: Both Message and Problem Detail/Instance ok.")
 	}
 }
+
+// HMSErrors wrapped by, or wrapping, other errors are still recognised.
+func TestHMSErrorWrapping(t *testing.T) {
+	cause := fmt.Errorf("connection reset")
+	child := ErrHMSStateInvalid.NewChild("'Bogus' is not a valid state")
+	wrapped := fmt.Errorf("update failed: %w", child.WithCause(cause))
+
+	if !IsHMSError(wrapped) {
+		t.Errorf("Testcase 1: FAIL: Wrapped HMSError not recognised")
+	}
+	if herr, ok := GetHMSError(wrapped); !ok || herr.Message != child.Message {
+		t.Errorf("Testcase 2: FAIL: GetHMSError() returned %v, %t", herr, ok)
+	}
+	if !errors.Is(wrapped, ErrHMSStateInvalid) || !errors.Is(wrapped, child) {
+		t.Errorf("Testcase 3: FAIL: Child did not match its ancestors")
+	}
+	if errors.Is(wrapped, ErrHMSNeedForce) || errors.Is(ErrHMSStateInvalid, child) {
+		t.Errorf("Testcase 4: FAIL: Matched a sibling or a descendant")
+	}
+	if !errors.Is(wrapped, NewHMSError("hms", "")) || errors.Is(wrapped, NewHMSError("other", "")) {
+		t.Errorf("Testcase 5: FAIL: Class-only match wrong")
+	}
+	if !errors.Is(wrapped, cause) || errors.Unwrap(child.WithCause(cause)) != cause {
+		t.Errorf("Testcase 6: FAIL: Cause not found in chain")
+	}
+	if wrapped.Error() != "update failed: "+child.Message {
+		t.Errorf("Testcase 7: FAIL: Cause leaked into Error(): %s", wrapped.Error())
+	}
+
+	// Class helpers search the whole chain, including joined errors.
+	inner := NewHMSError("inner", "inner problem")
+	outer := NewHMSError("outer", "outer problem").WithCause(inner)
+	if !IsHMSErrorClass(outer, "inner") || !IsHMSErrorClass(outer, "outer") ||
+		IsHMSErrorClass(outer, "other") {
+		t.Errorf("Testcase 8: FAIL: IsHMSErrorClass() didn't search the chain")
+	}
+	joined := errors.Join(fmt.Errorf("plain"), fmt.Errorf("wrapped: %w", inner))
+	if !IsHMSErrorClassIgnCase(joined, "INNER") || IsHMSErrorClass(nil, "inner") {
+		t.Errorf("Testcase 9: FAIL: IsHMSErrorClassIgnCase() didn't search joined errors")
+	}
+	if outer.Cause != inner || outer.NewChild("").Cause != inner {
+		t.Errorf("Testcase 10: FAIL: Cause not kept by children")
+	}
+}
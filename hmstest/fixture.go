@@ -0,0 +1,135 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+// Package hmstest helps test code that makes HTTP requests with hms-base's
+// HTTPRequest, without hand-writing an httptest server for every case.
+//
+// A Replayer is an http.RoundTripper that answers requests from canned
+// responses, either set up in the test with On(...).Respond(...) or loaded
+// from a fixture file recorded against a real service with a Recorder.  It
+// can also inject faults (delays, connection resets, sequences of 5xx
+// errors) to exercise retries, and check afterwards which requests were
+// made.  UseTransport() substitutes either into the shared HTTPClient for
+// the duration of a test:
+//
+//  func TestGetComponents(t *testing.T) {
+//      rp := hmstest.NewReplayer()
+//      rp.On(hmstest.MatchMethod("GET"), hmstest.MatchURLPath("/hsm/v2/State/Components")).
+//          Respond(hmstest.Status(http.StatusServiceUnavailable),
+//              hmstest.JSON(http.StatusOK, base.ComponentArray{}))
+//      hmstest.UseTransport(t, rp)
+//
+//      ... code under test, using base.HTTPRequest ...
+//
+//      rp.AssertAllMatched(t)
+//      rp.AssertRequestCount(t, 2)
+//  }
+package hmstest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"unicode/utf8"
+)
+
+// Contents of a fixture file: the exchanges recorded, in order.
+type Fixture struct {
+	Interactions []Interaction `json:"Interactions"`
+}
+
+// One recorded request and the response it got.
+type Interaction struct {
+	Request  FixtureRequest  `json:"Request"`
+	Response FixtureResponse `json:"Response"`
+}
+
+type FixtureRequest struct {
+	Method string      `json:"Method"`
+	URL    string      `json:"URL"`
+	Header http.Header `json:"Header,omitempty"`
+	Body   FixtureBody `json:"Body,omitempty"`
+}
+
+type FixtureResponse struct {
+	StatusCode int         `json:"StatusCode"`
+	Header     http.Header `json:"Header,omitempty"`
+	Body       FixtureBody `json:"Body,omitempty"`
+}
+
+// A request or response body.  Stored in the fixture file as a plain string
+// if it is valid UTF-8 (so fixtures can be read and edited by hand), and
+// base64-encoded in an object otherwise.
+type FixtureBody []byte
+
+func (b FixtureBody) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(b) {
+		return json.Marshal(string(b))
+	}
+	return json.Marshal(struct {
+		Base64 string `json:"base64"`
+	}{base64.StdEncoding.EncodeToString(b)})
+}
+
+func (b *FixtureBody) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		*b = FixtureBody(str)
+		return nil
+	}
+	var enc struct {
+		Base64 string `json:"base64"`
+	}
+	if err := json.Unmarshal(data, &enc); err != nil {
+		return fmt.Errorf("fixture body must be a string or {\"base64\": ...}: %w", err)
+	}
+	dec, err := base64.StdEncoding.DecodeString(enc.Base64)
+	if err != nil {
+		return err
+	}
+	*b = dec
+	return nil
+}
+
+// Read a fixture file.
+func LoadFixture(file string) (*Fixture, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	fixture := new(Fixture)
+	if err := json.Unmarshal(data, fixture); err != nil {
+		return nil, fmt.Errorf("unable to decode fixture %s: %w", file, err)
+	}
+	return fixture, nil
+}
+
+// Write a fixture file.
+func (f *Fixture) Save(file string) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(data, '\n'), 0644)
+}
@@ -0,0 +1,271 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package hmstest
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+)
+
+// Headers never written to fixture files, so recordings made against real
+// systems don't leak credentials.
+var RedactedHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-Auth-Token"}
+
+// JSON body fields whose values are never written to fixture files, e.g.
+// the Password of a Redfish session login.  As for base.HTTPLogger, whose
+// list this is by default, a field is redacted if its name, lower-cased,
+// contains any of these.  Replayers match recorded requests with redacted
+// fields whatever the values sent.
+var RedactedFields = base.DefaultRedactFields
+
+// Set this environment variable to make RecordOrReplay() record.
+const RecordEnvVar = "HMSTEST_RECORD"
+
+// A Recorder is an http.RoundTripper that passes requests on to another
+// transport and records each exchange, so it can be saved as a fixture and
+// replayed by a Replayer later.  Compressed bodies are recorded decompressed,
+// without their Content-Encoding.
+type Recorder struct {
+	next         http.RoundTripper
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+// Create a Recorder that sends requests on with 'next', or
+// http.DefaultTransport if nil.
+func NewRecorder(next http.RoundTripper) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{next: next}
+}
+
+func (rec *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := rec.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	base.DrainAndCloseResponseBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	// Bodies are recorded decompressed, so they can be redacted (and read).
+	reqHeader, reqBody := decodeBody(req.Header, reqBody)
+	respHeader, respBody := decodeBody(resp.Header, respBody)
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.interactions = append(rec.interactions, Interaction{
+		Request: FixtureRequest{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: redactHeader(reqHeader),
+			Body:   redactBody(reqBody, reqHeader.Get("Content-Type")),
+		},
+		Response: FixtureResponse{
+			StatusCode: resp.StatusCode,
+			Header:     redactHeader(respHeader),
+			Body:       redactBody(respBody, respHeader.Get("Content-Type")),
+		},
+	})
+	return resp, nil
+}
+
+// Returns everything recorded so far.
+func (rec *Recorder) Fixture() *Fixture {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return &Fixture{Interactions: append([]Interaction{}, rec.interactions...)}
+}
+
+// Write everything recorded so far to a fixture file.
+func (rec *Recorder) Save(file string) error {
+	return rec.Fixture().Save(file)
+}
+
+func redactHeader(h http.Header) http.Header {
+	h = h.Clone()
+	for _, name := range RedactedHeaders {
+		h.Del(name)
+	}
+	if len(h) == 0 {
+		return nil
+	}
+	return h
+}
+
+// Returns 'body' decompressed according to the Content-Encoding in
+// 'header', and 'header' without it (or the Content-Length, which was of the
+// compressed body).  Bodies that aren't compressed, or can't be
+// decompressed, are returned as they are.
+func decodeBody(header http.Header, body []byte) (http.Header, []byte) {
+	var r io.Reader
+	var err error
+	switch strings.ToLower(strings.TrimSpace(header.Get("Content-Encoding"))) {
+	case "gzip", "x-gzip":
+		r, err = gzip.NewReader(bytes.NewReader(body))
+	case "deflate":
+		if r, err = zlib.NewReader(bytes.NewReader(body)); err != nil {
+			r, err = flate.NewReader(bytes.NewReader(body)), nil
+		}
+	default:
+		return header, body
+	}
+	if err != nil {
+		return header, body
+	}
+	decoded, err := io.ReadAll(r)
+	if err != nil {
+		return header, body
+	}
+	header = header.Clone()
+	header.Del("Content-Encoding")
+	header.Del("Content-Length")
+	return header, decoded
+}
+
+// Returns 'body' with the values of any RedactedFields replaced, if it is
+// JSON.  Other bodies, and JSON with nothing to redact, are returned as-is.
+func redactBody(body []byte, contentType string) []byte {
+	ct := strings.ToLower(contentType)
+	if len(body) == 0 || (ct != "" && !strings.Contains(ct, "json")) {
+		return body
+	}
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil || !redactFields(v) {
+		return body
+	}
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return body
+	}
+	return bytes.TrimSuffix(out.Bytes(), []byte("\n"))
+}
+
+// Redact RedactedFields in the decoded JSON 'v'.  Returns true if there
+// were any.
+func redactFields(v interface{}) bool {
+	redacted := false
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for key, sub := range val {
+			if sensitiveField(key) {
+				val[key] = base.Redacted
+				redacted = true
+			} else if redactFields(sub) {
+				redacted = true
+			}
+		}
+	case []interface{}:
+		for _, sub := range val {
+			if redactFields(sub) {
+				redacted = true
+			}
+		}
+	}
+	return redacted
+}
+
+func sensitiveField(name string) bool {
+	name = strings.ToLower(name)
+	for _, f := range RedactedFields {
+		if strings.Contains(name, f) {
+			return true
+		}
+	}
+	return false
+}
+
+// Read the whole body of 'req', leaving it in place to be sent.
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// Substitute 'rt' for the transport of the shared HTTPClient (see
+// base.HTTPClient.SetTransport()) until the end of the test.  HTTPRequests
+// that set their own Client are not affected.
+func UseTransport(t testing.TB, rt http.RoundTripper) {
+	orig := base.GetSharedHTTPClient()
+	client := base.NewHTTPClient()
+	client.SetTransport(rt)
+	base.SetSharedHTTPClient(client)
+	t.Cleanup(func() {
+		base.SetSharedHTTPClient(orig)
+	})
+}
+
+// Record against real services or replay a fixture, depending on the
+// environment, and install the result with UseTransport().
+//
+// If RecordEnvVar is set, requests go to the real services and are saved
+// to 'file' at the end of the test.  Otherwise they are answered by a
+// Replayer from 'file', which must exist.
+func RecordOrReplay(t testing.TB, file string) {
+	t.Helper()
+	if os.Getenv(RecordEnvVar) != "" {
+		rec := NewRecorder(nil)
+		UseTransport(t, rec)
+		t.Cleanup(func() {
+			if err := rec.Save(file); err != nil {
+				t.Errorf("Unable to save fixture %s: %v", file, err)
+			}
+		})
+		return
+	}
+	rp, err := NewReplayerFromFile(file)
+	if err != nil {
+		t.Fatalf("Unable to load fixture: %v", err)
+	}
+	UseTransport(t, rp)
+}
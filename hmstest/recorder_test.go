@@ -0,0 +1,171 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package hmstest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	polls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/task":
+			polls++
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"Poll": ` + strings.Repeat("1", polls) + `}`))
+		case "/binary":
+			w.Write([]byte{0xff, 0x00, 0xfe})
+		}
+	}))
+	defer srv.Close()
+
+	file := filepath.Join(t.TempDir(), "fixture.json")
+
+	// Record
+	rec := NewRecorder(nil)
+	client := base.NewHTTPClient()
+	client.SetTransport(rec)
+	var recorded []string
+	for _, path := range []string{"/task", "/task", "/binary"} {
+		request := base.NewHTTPRequest(srv.URL + path)
+		request.Client = client
+		request.Auth = &base.Auth{Username: "root", Password: "secret"}
+		payload, err := request.DoHTTPAction()
+		if err != nil {
+			t.Fatalf("Recording request failed: %v", err)
+		}
+		recorded = append(recorded, string(payload))
+	}
+	if err := rec.Save(file); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	data, _ := os.ReadFile(file)
+	if strings.Contains(string(data), "Authorization") {
+		t.Errorf("Credentials saved in fixture: %s", data)
+	}
+	srv.Close()
+
+	// Replay, with the server gone.
+	rp, err := NewReplayerFromFile(file)
+	if err != nil {
+		t.Fatalf("NewReplayerFromFile() failed: %v", err)
+	}
+	client.SetTransport(rp)
+	for i, path := range []string{"/task", "/task", "/binary"} {
+		request := base.NewHTTPRequest(srv.URL + path)
+		request.Client = client
+		payload, err := request.DoHTTPAction()
+		if err != nil {
+			t.Fatalf("Replayed request %d failed: %v", i, err)
+		}
+		if string(payload) != recorded[i] {
+			t.Errorf("Request %d: recorded '%s', replayed '%s'", i, recorded[i], payload)
+		}
+	}
+	rp.AssertAllMatched(t)
+}
+
+func TestRecordRedactsBodies(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"Id":"1","Oem":{"SessionToken":"returned-token"}}`))
+	})
+	// Compressed both ways, bodies are still decompressed and redacted.
+	for _, compress := range []bool{false, true} {
+		var srv *httptest.Server
+		if compress {
+			srv = httptest.NewServer(base.CompressionMiddleware(0)(handler))
+		} else {
+			srv = httptest.NewServer(handler)
+		}
+		defer srv.Close()
+
+		login := func(client *base.HTTPClient, password string) []byte {
+			request := base.NewHTTPRequest(srv.URL + "/redfish/v1/SessionService/Sessions")
+			request.Client = client
+			request.Method = http.MethodPost
+			request.Payload = []byte(`{"UserName":"root","Password":"` + password + `"}`)
+			if compress {
+				request.CompressMinBytes = 1
+			}
+			payload, err := request.DoHTTPAction()
+			if err != nil {
+				t.Fatalf("Login failed (compressed %t): %v", compress, err)
+			}
+			return payload
+		}
+
+		rec := NewRecorder(nil)
+		client := base.NewHTTPClient()
+		client.SetTransport(rec)
+		if payload := login(client, "hunter2"); !strings.Contains(string(payload), "returned-token") {
+			t.Errorf("Caller didn't get the real response (compressed %t): %s", compress, payload)
+		}
+		file := filepath.Join(t.TempDir(), "fixture.json")
+		if err := rec.Save(file); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+		data, _ := os.ReadFile(file)
+		if strings.Contains(string(data), "hunter2") || strings.Contains(string(data), "returned-token") ||
+			!strings.Contains(string(data), "root") || strings.Contains(string(data), "Content-Encoding") {
+			t.Errorf("Fixture not redacted properly (compressed %t): %s", compress, data)
+		}
+
+		// Replayed whatever the password.
+		rp, err := NewReplayerFromFile(file)
+		if err != nil {
+			t.Fatalf("NewReplayerFromFile() failed: %v", err)
+		}
+		client.SetTransport(rp)
+		if payload := login(client, "other"); !strings.Contains(string(payload), `"Id":"1"`) {
+			t.Errorf("Unexpected replayed response (compressed %t): %s", compress, payload)
+		}
+		rp.AssertAllMatched(t)
+	}
+}
+
+func TestRecordOrReplay(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "fixture.json")
+	fixture := &Fixture{Interactions: []Interaction{{
+		Request:  FixtureRequest{Method: "GET", URL: "http://cray-smd/hsm/v2/service/ready"},
+		Response: FixtureResponse{StatusCode: http.StatusOK, Body: FixtureBody("ready")},
+	}}}
+	if err := fixture.Save(file); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	os.Unsetenv(RecordEnvVar)
+	RecordOrReplay(t, file)
+	payload, err := base.NewHTTPRequest("http://cray-smd/hsm/v2/service/ready").DoHTTPAction()
+	if err != nil || string(payload) != "ready" {
+		t.Errorf("Expected 'ready' from fixture, got '%s', %v", payload, err)
+	}
+}
@@ -0,0 +1,430 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package hmstest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////
+// Matchers
+////////////////////////////////////////////////////////////////////////////
+
+// A Matcher decides whether a request (with its already-read body) should
+// get a Route's responses.
+type Matcher func(req *http.Request, body []byte) bool
+
+func MatchMethod(method string) Matcher {
+	return func(req *http.Request, body []byte) bool {
+		return strings.EqualFold(req.Method, method)
+	}
+}
+
+// Match the full URL, including scheme, host and query.
+func MatchURL(url string) Matcher {
+	return func(req *http.Request, body []byte) bool {
+		return req.URL.String() == url
+	}
+}
+
+// Match the URL path only.
+func MatchURLPath(path string) Matcher {
+	return func(req *http.Request, body []byte) bool {
+		return req.URL.Path == path
+	}
+}
+
+// Match requests with query parameter 'key' set to 'val'.
+func MatchQuery(key, val string) Matcher {
+	return func(req *http.Request, body []byte) bool {
+		return req.URL.Query().Get(key) == val
+	}
+}
+
+// Match requests with header 'name' set to 'val'.
+func MatchHeader(name, val string) Matcher {
+	return func(req *http.Request, body []byte) bool {
+		return req.Header.Get(name) == val
+	}
+}
+
+// Match the request body exactly.
+func MatchBody(want []byte) Matcher {
+	return func(req *http.Request, body []byte) bool {
+		return bytes.Equal(body, want)
+	}
+}
+
+// Match request bodies that are 'want' once redacted as a Recorder would.
+func matchRedactedBody(want []byte) Matcher {
+	return func(req *http.Request, body []byte) bool {
+		return bytes.Equal(redactBody(body, req.Header.Get("Content-Type")), want)
+	}
+}
+
+// Match request bodies that contain 'substr'.
+func MatchBodyContains(substr string) Matcher {
+	return func(req *http.Request, body []byte) bool {
+		return bytes.Contains(body, []byte(substr))
+	}
+}
+
+// Match request bodies that are JSON equivalent to 'v' once both are
+// decoded, so formatting and member order don't matter.
+func MatchJSONBody(v interface{}) Matcher {
+	wantJSON, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("hmstest: MatchJSONBody: %v", err))
+	}
+	return func(req *http.Request, body []byte) bool {
+		return bytes.Equal(normalizeJSON(body), normalizeJSON(wantJSON))
+	}
+}
+
+// Re-encode JSON with object members sorted, or nil if it isn't valid.
+func normalizeJSON(data []byte) []byte {
+	var v interface{}
+	if json.Unmarshal(data, &v) != nil {
+		return nil
+	}
+	norm, _ := json.Marshal(v)
+	return norm
+}
+
+func matchAll(matchers []Matcher, req *http.Request, body []byte) bool {
+	for _, m := range matchers {
+		if !m(req, body) {
+			return false
+		}
+	}
+	return true
+}
+
+////////////////////////////////////////////////////////////////////////////
+// Responses and faults
+////////////////////////////////////////////////////////////////////////////
+
+// A canned response.  If Err is set, the request fails with that transport
+// error instead of getting a response.  Either way, it happens after Delay.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	Delay      time.Duration
+	Err        error
+}
+
+// Response with 'code' and no body.
+func Status(code int) *Response {
+	return &Response{StatusCode: code, Header: http.Header{}}
+}
+
+// Response with 'code' and 'body' as plain text.
+func Text(code int, body string) *Response {
+	resp := Status(code)
+	resp.Header.Set("Content-Type", "text/plain")
+	resp.Body = []byte(body)
+	return resp
+}
+
+// Response with 'code' and 'v' encoded as a JSON body.
+func JSON(code int, v interface{}) *Response {
+	body, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("hmstest: JSON: %v", err))
+	}
+	resp := Status(code)
+	resp.Header.Set("Content-Type", "application/json")
+	resp.Body = body
+	return resp
+}
+
+// The request fails as if the server reset the connection.
+func ConnectionReset() *Response {
+	return &Response{Err: &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}}
+}
+
+// Set a header on the response.
+func (r *Response) WithHeader(name, val string) *Response {
+	if r.Header == nil {
+		r.Header = http.Header{}
+	}
+	r.Header.Set(name, val)
+	return r
+}
+
+// Delay the response by 'd', or until the request is cancelled.
+func (r *Response) WithDelay(d time.Duration) *Response {
+	r.Delay = d
+	return r
+}
+
+////////////////////////////////////////////////////////////////////////////
+// Routes
+////////////////////////////////////////////////////////////////////////////
+
+// A Route gives requests that match all of its Matchers its Responses, in
+// order.  Once they have all been used the last one is repeated, so
+//
+//  rp.On(hmstest.MatchURLPath("/x")).Respond(
+//      hmstest.Status(503), hmstest.Status(503), hmstest.Text(200, "ok"))
+//
+// fails twice and then succeeds from then on.
+type Route struct {
+	mu        sync.Mutex
+	matchers  []Matcher
+	responses []*Response
+	count     int
+}
+
+// Add responses for the route to give, in order.
+func (r *Route) Respond(resp ...*Response) *Route {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.responses = append(r.responses, resp...)
+	return r
+}
+
+// Number of requests the route has answered.
+func (r *Route) Count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.count
+}
+
+func (r *Route) hasResponses() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.responses) > 0
+}
+
+func (r *Route) next() *Response {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := r.count
+	if i >= len(r.responses) {
+		i = len(r.responses) - 1
+	}
+	r.count++
+	return r.responses[i]
+}
+
+////////////////////////////////////////////////////////////////////////////
+// Replayer
+////////////////////////////////////////////////////////////////////////////
+
+// A request seen by a Replayer.
+type RecordedRequest struct {
+	*http.Request
+	Body    []byte // Decompressed, if it was sent compressed
+	Matched bool
+}
+
+// A Replayer is an http.RoundTripper that answers requests from its Routes
+// instead of sending them anywhere.  Routes are tried in the order they were
+// added.  Requests no Route matches get a 501 Not Implemented response
+// (which HTTPRequest won't retry) and show up in AssertAllMatched().
+type Replayer struct {
+	mu       sync.Mutex
+	routes   []*Route
+	requests []*RecordedRequest
+}
+
+func NewReplayer() *Replayer {
+	return new(Replayer)
+}
+
+// Create a Replayer for the interactions in a fixture file.
+func NewReplayerFromFile(file string) (*Replayer, error) {
+	fixture, err := LoadFixture(file)
+	if err != nil {
+		return nil, err
+	}
+	rp := NewReplayer()
+	rp.AddFixture(fixture)
+	return rp, nil
+}
+
+// Add a Route for requests matching all of 'matchers' (or all requests, if
+// there are none).  Give it something to respond with using Respond().
+func (rp *Replayer) On(matchers ...Matcher) *Route {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	route := &Route{matchers: matchers}
+	rp.routes = append(rp.routes, route)
+	return route
+}
+
+// Add Routes replaying the interactions in 'fixture'.  Requests are matched
+// on method, URL and body (with RedactedFields redacted, as when recorded).
+// If the same request was recorded more than once, e.g. while polling, its
+// responses are replayed in the order recorded.
+func (rp *Replayer) AddFixture(fixture *Fixture) {
+	type key struct{ method, url, body string }
+	routes := map[key]*Route{}
+	for _, in := range fixture.Interactions {
+		k := key{strings.ToUpper(in.Request.Method), in.Request.URL, string(in.Request.Body)}
+		route, ok := routes[k]
+		if !ok {
+			route = rp.On(MatchMethod(k.method), MatchURL(k.url), matchRedactedBody(in.Request.Body))
+			routes[k] = route
+		}
+		route.Respond(&Response{
+			StatusCode: in.Response.StatusCode,
+			Header:     in.Response.Header,
+			Body:       in.Response.Body,
+		})
+	}
+}
+
+func (rp *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	// Match compressed bodies as they were recorded, decompressed.
+	_, body = decodeBody(req.Header, body)
+	recorded := &RecordedRequest{Request: req, Body: body}
+
+	rp.mu.Lock()
+	rp.requests = append(rp.requests, recorded)
+	var route *Route
+	for _, r := range rp.routes {
+		if r.hasResponses() && matchAll(r.matchers, req, body) {
+			route = r
+			break
+		}
+	}
+	recorded.Matched = route != nil
+	rp.mu.Unlock()
+
+	if route == nil {
+		return newHTTPResponse(req, Text(http.StatusNotImplemented,
+			fmt.Sprintf("hmstest: no route matches %s %s", req.Method, req.URL))), nil
+	}
+	resp := route.next()
+
+	if resp.Delay > 0 {
+		timer := time.NewTimer(resp.Delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+	if resp.Err != nil {
+		return nil, resp.Err
+	}
+	return newHTTPResponse(req, resp), nil
+}
+
+func newHTTPResponse(req *http.Request, resp *Response) *http.Response {
+	header := resp.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode)),
+		StatusCode:    resp.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(resp.Body)),
+		ContentLength: int64(len(resp.Body)),
+		Request:       req,
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////
+// Assertions
+////////////////////////////////////////////////////////////////////////////
+
+// Returns every request seen so far, in order.
+func (rp *Replayer) Requests() []*RecordedRequest {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	return append([]*RecordedRequest{}, rp.requests...)
+}
+
+// Number of requests seen so far that match all of 'matchers'.
+func (rp *Replayer) CountRequests(matchers ...Matcher) int {
+	count := 0
+	for _, req := range rp.Requests() {
+		if matchAll(matchers, req.Request, req.Body) {
+			count++
+		}
+	}
+	return count
+}
+
+// Fail the test unless exactly 'n' requests have been seen.
+func (rp *Replayer) AssertRequestCount(t testing.TB, n int) {
+	t.Helper()
+	if got := len(rp.Requests()); got != n {
+		t.Errorf("Expected %d requests, got %d", n, got)
+	}
+}
+
+// Fail the test unless at least one request matching all of 'matchers' has
+// been seen.
+func (rp *Replayer) AssertRequested(t testing.TB, matchers ...Matcher) {
+	t.Helper()
+	if rp.CountRequests(matchers...) == 0 {
+		t.Errorf("Expected a matching request, got none")
+	}
+}
+
+// Fail the test if any request did not match a Route.
+func (rp *Replayer) AssertAllMatched(t testing.TB) {
+	t.Helper()
+	for _, req := range rp.Requests() {
+		if !req.Matched {
+			t.Errorf("Unexpected request: %s %s", req.Method, req.URL)
+		}
+	}
+}
+
+// Fail the test if any Route has not answered at least one request.
+func (rp *Replayer) AssertAllUsed(t testing.TB) {
+	t.Helper()
+	rp.mu.Lock()
+	routes := append([]*Route{}, rp.routes...)
+	rp.mu.Unlock()
+	for i, route := range routes {
+		if route.Count() == 0 {
+			t.Errorf("Route %d was never used", i)
+		}
+	}
+}
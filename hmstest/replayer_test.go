@@ -0,0 +1,166 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package hmstest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+)
+
+func TestReplayerRoutes(t *testing.T) {
+	rp := NewReplayer()
+	rp.On(MatchMethod("GET"), MatchURLPath("/hsm/v2/State/Components"), MatchQuery("type", "Node")).
+		Respond(JSON(http.StatusOK, base.ComponentArray{
+			Components: []*base.Component{{ID: "x0c0s0b0n0"}},
+		}))
+	rp.On(MatchMethod("POST"), MatchJSONBody(map[string]string{"State": "On", "Flag": "OK"})).
+		Respond(Status(http.StatusNoContent).WithHeader("X-Test", "yes"))
+	UseTransport(t, rp)
+
+	request := base.NewHTTPRequest("http://cray-smd/hsm/v2/State/Components?type=Node")
+	payload, err := request.DoHTTPAction()
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	if !strings.Contains(string(payload), "x0c0s0b0n0") {
+		t.Errorf("Unexpected GET payload: %s", payload)
+	}
+
+	request = base.NewHTTPRequest("http://cray-smd/hsm/v2/State/Components/x0c0s0b0n0")
+	request.Method = "POST"
+	request.Payload = []byte(`{"Flag":"OK",  "State":"On"}`)
+	request.ExpectedStatusCode = http.StatusNoContent
+	if _, err := request.DoHTTPAction(); err != nil {
+		t.Errorf("POST failed: %v", err)
+	}
+
+	rp.AssertAllMatched(t)
+	rp.AssertAllUsed(t)
+	rp.AssertRequestCount(t, 2)
+	rp.AssertRequested(t, MatchMethod("POST"), MatchBodyContains(`"State":"On"`))
+
+	// Anything else gets a 501, which isn't retried.
+	request = base.NewHTTPRequest("http://cray-smd/other")
+	start := time.Now()
+	if _, err := request.DoHTTPAction(); err == nil {
+		t.Errorf("Expected error from unmatched request")
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("Unmatched request was retried")
+	}
+	reqs := rp.Requests()
+	if len(reqs) != 3 || reqs[2].Matched || reqs[2].URL.Path != "/other" {
+		t.Errorf("Unmatched request not recorded properly: %+v", reqs[len(reqs)-1])
+	}
+	if rp.CountRequests(MatchMethod("GET")) != 2 {
+		t.Errorf("Expected 2 GET requests, got %d", rp.CountRequests(MatchMethod("GET")))
+	}
+}
+
+func TestReplayerFaults(t *testing.T) {
+	rp := NewReplayer()
+	flaky := rp.On(MatchURLPath("/flaky")).
+		Respond(Status(http.StatusServiceUnavailable), ConnectionReset(), Text(http.StatusOK, "ok"))
+	rp.On(MatchURLPath("/slow")).Respond(Text(http.StatusOK, "slow").WithDelay(time.Hour))
+	UseTransport(t, rp)
+
+	// Retried through a 503 and a reset.
+	payload, err := base.NewHTTPRequest("http://bmc/flaky").DoHTTPAction()
+	if err != nil || string(payload) != "ok" {
+		t.Errorf("Expected 'ok' after retries, got '%s', %v", payload, err)
+	}
+	if flaky.Count() != 3 {
+		t.Errorf("Expected 3 attempts, got %d", flaky.Count())
+	}
+	// Last response repeats.
+	payload, _ = base.NewHTTPRequest("http://bmc/flaky").DoHTTPAction()
+	if string(payload) != "ok" {
+		t.Errorf("Expected last response to repeat, got '%s'", payload)
+	}
+
+	// Delays give way to the request context.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	request := base.NewHTTPRequest("http://bmc/slow")
+	request.Context = ctx
+	if _, err := request.DoHTTPAction(); !errors.Is(err, context.DeadlineExceeded) &&
+		!strings.Contains(err.Error(), "deadline") {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+}
+
+// Run with -race: requests and checks from several goroutines at once.
+func TestReplayerConcurrent(t *testing.T) {
+	rp := NewReplayer()
+	rp.On(MatchURLPath("/ok")).Respond(Text(http.StatusOK, "ok"))
+
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			for j := 0; j < 20; j++ {
+				req, _ := http.NewRequest("GET", "http://cray-smd/ok", nil)
+				if _, err := rp.RoundTrip(req); err != nil {
+					t.Errorf("RoundTrip failed: %v", err)
+				}
+				matched := 0
+				for _, req := range rp.Requests() {
+					if req.Matched {
+						matched++
+					}
+				}
+				if matched == 0 {
+					t.Errorf("No requests matched")
+				}
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	rp.AssertAllMatched(t)
+	rp.AssertRequestCount(t, 16*20)
+}
+
+func TestReplayerOwnClient(t *testing.T) {
+	rp := NewReplayer()
+	rp.On().Respond(Text(http.StatusOK, "mine"))
+
+	client := base.NewHTTPClient()
+	client.SetTransport(rp)
+	request := base.NewHTTPRequest("https://anything/at/all")
+	request.Client = client
+	payload, err := request.DoHTTPAction()
+	if err != nil || string(payload) != "mine" {
+		t.Errorf("Expected 'mine', got '%s', %v", payload, err)
+	}
+}
@@ -0,0 +1,254 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package base
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limits on the requests an HTTPClient makes to one host.  Zero values
+// mean no limit.
+type HostLimit struct {
+	MaxInFlight       int     // Requests in progress at once, until their response body is closed
+	RequestsPerSecond float64 // Sustained rate of new requests
+	Burst             int     // Requests allowed at once before the rate applies (at least 1)
+}
+
+// Limits the requests made to each host, so that a service can't swamp a
+// BMC (or anything else) with more requests than it can handle.  Requests
+// over the limit wait their turn, or until their Context is done.  Each
+// retry of a request counts as a new request.
+//
+// Every host gets the default limit unless it matches a pattern set with
+// SetHostLimit().  Patterns are path.Match patterns on the host name
+// (without port), e.g. "x3000c0s*b0" or "*.hmn".
+//
+//  limiter := base.NewHostLimiter(base.HostLimit{MaxInFlight: 4, RequestsPerSecond: 10})
+//  limiter.SetHostLimit("x9000*", base.HostLimit{MaxInFlight: 1})
+//  base.GetSharedHTTPClient().SetHostLimiter(limiter)
+type HostLimiter struct {
+	mu        sync.Mutex
+	def       HostLimit
+	overrides []hostLimitOverride
+	hosts     map[string]*hostState
+}
+
+type hostLimitOverride struct {
+	pattern string
+	limit   HostLimit
+}
+
+// Limiting state of one host.
+type hostState struct {
+	limit  HostLimit
+	slots  chan struct{} // nil if no MaxInFlight
+	tokens float64
+	last   time.Time
+}
+
+// Create a HostLimiter with 'def' as the limit for every host.
+func NewHostLimiter(def HostLimit) *HostLimiter {
+	return &HostLimiter{def: def, hosts: map[string]*hostState{}}
+}
+
+// Set the limit for hosts matching 'pattern', replacing any earlier limit
+// for the same pattern.  A host matching several patterns gets the limit of
+// the first one set.  Returns an error if the pattern is malformed.
+//
+// Requests already waiting or in progress are counted against the old
+// limit.
+func (l *HostLimiter) SetHostLimit(pattern string, limit HostLimit) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("bad host pattern %q: %w", pattern, err)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hosts = map[string]*hostState{}
+	for i := range l.overrides {
+		if l.overrides[i].pattern == pattern {
+			l.overrides[i].limit = limit
+			return nil
+		}
+	}
+	l.overrides = append(l.overrides, hostLimitOverride{pattern: pattern, limit: limit})
+	return nil
+}
+
+// Returns the limit that applies to 'host' (with or without a port).
+func (l *HostLimiter) HostLimit(host string) HostLimit {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limitFor(host)
+}
+
+func (l *HostLimiter) limitFor(host string) HostLimit {
+	name := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		name = h
+	}
+	name = strings.ToLower(name)
+	for _, o := range l.overrides {
+		if ok, _ := path.Match(o.pattern, name); ok {
+			return o.limit
+		}
+	}
+	return l.def
+}
+
+func (l *HostLimiter) state(host string) *hostState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.hosts == nil {
+		l.hosts = map[string]*hostState{}
+	}
+	st, ok := l.hosts[host]
+	if !ok {
+		limit := l.limitFor(host)
+		if limit.Burst < 1 {
+			limit.Burst = 1
+		}
+		st = &hostState{limit: limit, tokens: float64(limit.Burst), last: time.Now()}
+		if limit.MaxInFlight > 0 {
+			st.slots = make(chan struct{}, limit.MaxInFlight)
+		}
+		l.hosts[host] = st
+	}
+	return st
+}
+
+// Wait until a request to 'host' may be made, or 'ctx' is done.  On
+// success, the returned function must be called once the request is over.
+func (l *HostLimiter) Wait(ctx context.Context, host string) (release func(), err error) {
+	st := l.state(host)
+
+	if st.slots != nil {
+		select {
+		case st.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	free := func() {
+		if st.slots != nil {
+			<-st.slots
+		}
+	}
+
+	if st.limit.RequestsPerSecond > 0 {
+		delay := l.reserve(st)
+		if delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				l.unreserve(st)
+				free()
+				return nil, ctx.Err()
+			}
+		}
+	}
+
+	var once sync.Once
+	return func() { once.Do(free) }, nil
+}
+
+// Take a token from the host's bucket, returning how long to wait for it to
+// be there.  Tokens may be taken in advance, so waiters are served in
+// order.
+func (l *HostLimiter) reserve(st *hostState) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	st.tokens += now.Sub(st.last).Seconds() * st.limit.RequestsPerSecond
+	if burst := float64(st.limit.Burst); st.tokens > burst {
+		st.tokens = burst
+	}
+	st.last = now
+	st.tokens--
+	if st.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-st.tokens / st.limit.RequestsPerSecond * float64(time.Second))
+}
+
+// Give back a token taken by a request that gave up waiting.
+func (l *HostLimiter) unreserve(st *hostState) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	st.tokens++
+}
+
+// Wrap 'next' so requests made through it are limited.  A request stays in
+// flight until its response body is closed.
+func (l *HostLimiter) RoundTripper(next http.RoundTripper) http.RoundTripper {
+	return &limitedTransport{limiter: l, next: next}
+}
+
+type limitedTransport struct {
+	limiter *HostLimiter
+	next    http.RoundTripper
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	release, err := t.limiter.Wait(req.Context(), req.URL.Host)
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+func (t *limitedTransport) CloseIdleConnections() {
+	if closer, ok := t.next.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// Response body that releases its request's limiter slot when closed.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
@@ -0,0 +1,173 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package base
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHostLimiterMaxInFlight(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, maxSeen := 0, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxSeen {
+			maxSeen = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	client := NewHTTPClient()
+	client.SetHostLimiter(NewHostLimiter(HostLimit{MaxInFlight: 2}))
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			request := NewHTTPRequest(srv.URL)
+			request.Client = client
+			if _, err := request.DoHTTPAction(); err != nil {
+				t.Errorf("Request failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if maxSeen != 2 {
+		t.Errorf("Expected at most (and up to) 2 requests in flight, saw %d", maxSeen)
+	}
+}
+
+func TestHostLimiterRate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	client := NewHTTPClient()
+	client.SetHostLimiter(NewHostLimiter(HostLimit{RequestsPerSecond: 50, Burst: 2}))
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		request := NewHTTPRequest(srv.URL)
+		request.Client = client
+		if _, err := request.DoHTTPAction(); err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+	}
+	// 2 right away, then 4 at 20ms intervals.
+	if elapsed := time.Since(start); elapsed < 75*time.Millisecond {
+		t.Errorf("Requests not rate limited, took %v", elapsed)
+	}
+}
+
+func TestHostLimiterContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	client := NewHTTPClient()
+	client.SetHostLimiter(NewHostLimiter(HostLimit{MaxInFlight: 1}))
+
+	request := NewHTTPRequest(srv.URL)
+	request.Client = client
+	held, err := request.DoHTTPResponse()
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+
+	// The first request is in flight until its body is closed.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	waiting := NewHTTPRequest(srv.URL)
+	waiting.Client = client
+	waiting.Context = ctx
+	if _, err := waiting.DoHTTPAction(); err == nil {
+		t.Errorf("Expected request over the limit to time out")
+	}
+
+	held.Body.Close()
+	if _, err := request.DoHTTPAction(); err != nil {
+		t.Errorf("Request failed after slot released: %v", err)
+	}
+
+	// Waiting on the limiter directly.
+	l := NewHostLimiter(HostLimit{MaxInFlight: 1})
+	release, err := l.Wait(context.Background(), "bmc:443")
+	if err != nil {
+		t.Fatalf("Wait failed: %v", err)
+	}
+	if _, err := l.Wait(ctx, "bmc:443"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected DeadlineExceeded, got %v", err)
+	}
+	if r, err := l.Wait(context.Background(), "other:443"); err != nil {
+		t.Errorf("Other host limited: %v", err)
+	} else {
+		r()
+	}
+	release()
+	release() // No effect the second time
+	if r, err := l.Wait(context.Background(), "bmc:443"); err != nil {
+		t.Errorf("Wait failed after release: %v", err)
+	} else {
+		r()
+	}
+}
+
+func TestHostLimiterOverrides(t *testing.T) {
+	l := NewHostLimiter(HostLimit{MaxInFlight: 8})
+	if err := l.SetHostLimit("x9000*", HostLimit{MaxInFlight: 1}); err != nil {
+		t.Fatalf("SetHostLimit failed: %v", err)
+	}
+	l.SetHostLimit("*.hmn", HostLimit{MaxInFlight: 2})
+	l.SetHostLimit("x9000*", HostLimit{MaxInFlight: 3})
+
+	tests := []struct {
+		host     string
+		expected int
+	}{
+		{"x9000c1s0b0:443", 3},
+		{"X9000c1s0b0", 3},
+		{"x9000c1s0b0.hmn", 3},
+		{"x3000c0s1b0.hmn:8443", 2},
+		{"x3000c0s1b0", 8},
+		{"[::1]:443", 8},
+	}
+	for _, tt := range tests {
+		if limit := l.HostLimit(tt.host); limit.MaxInFlight != tt.expected {
+			t.Errorf("%s: expected MaxInFlight %d, got %d", tt.host, tt.expected, limit.MaxInFlight)
+		}
+	}
+	if err := l.SetHostLimit("[x", HostLimit{}); err == nil {
+		t.Errorf("Expected error for malformed pattern")
+	}
+}
@@ -1,6 +1,6 @@
 // MIT License
 //
-// (C) Copyright [2019-2021,2025] Hewlett Packard Enterprise Development LP
+// (C) Copyright [2019-2021,2025-2026] Hewlett Packard Enterprise Development LP
 //
 // Permission is hereby granted, free of charge, to any person obtaining a
 // copy of this software and associated documentation files (the "Software"),
@@ -25,13 +25,16 @@ package base
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/go-retryablehttp"
@@ -45,10 +48,42 @@ type HTTPRequest struct {
 	Method             string          // HTTP method to use.
 	Payload            []byte          // Bytes payload to pass if desired of ContentType.
 	Auth               *Auth           // Basic authentication if necessary using Auth struct.
-	Timeout            time.Duration   // Timeout for entire transaction.
+	Timeout            time.Duration   // Limit on each attempt, if AttemptTimeout is not set.
+	AttemptTimeout     time.Duration   // Limit on each attempt, including reading the response body.
+	OverallTimeout     time.Duration   // Limit on the whole request, retries and backoff included, 0 for none.
 	SkipTLSVerify      bool            // Ignore TLS verification errors?
-	ExpectedStatusCode int             // Expected HTTP status return code.
+	ExpectedStatusCode int             // Expected HTTP status return code, 0 for any 2xx.
 	ContentType        string          // HTTP content type of Payload.
+	MaxResponseBytes   int64           // Maximum response body size accepted, 0 for no limit.
+	Client             *HTTPClient     // Client (transport, TLS settings) to use, nil for the shared one.
+	Header             http.Header     // Additional request headers, if any.
+	Body               *RequestBody    // Streamed request body, instead of Payload.
+	OnProgress         ProgressFunc    // Called as the request body is sent, if set.
+	Cache              *ResponseCache  // Cache for GET responses, if any.
+	IfMatch            string          // ETag the resource must still have (412 if not), e.g. for a safe PATCH.
+	CompressMinBytes   int64           // Gzip request bodies of at least this size, 0 for never.
+	Logger             *HTTPLogger     // Debug logging of the exchange, nil for the default.
+}
+
+// Returned (possibly wrapped) when a response body is larger than the
+// HTTPRequest's MaxResponseBytes.
+var ErrHTTPResponseTooLarge = errors.New("response body exceeds maximum allowed size")
+
+// Most of an unexpected response's body kept in an HTTPStatusError.
+const maxStatusErrorBodyBytes = 64 * 1024
+
+// Error returned by DoHTTPAction and friends when the response status code
+// is not the one expected.  The start of the response body is kept, as it
+// often explains the problem (e.g. an RFC 9457 ProblemDetails, see
+// ParseProblemDetails(), or a Redfish error).
+type HTTPStatusError struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte // At most the first 64KiB
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("received unexpected status code: %d", e.StatusCode)
 }
 
 // These are used to reduce duplication when adding User-Agent headers to requests.
@@ -59,27 +94,27 @@ func GetServiceInstanceName() (string, error) {
 	return os.Hostname()
 }
 
+// Add 'instName' to the User-Agent of 'req', unless it is already there.
+// There is only ever one User-Agent header; 'instName' is appended to any
+// existing value as another product.  HTTPRequests set a User-Agent from
+// their HTTPClient's ServiceIdentity, which is usually what is wanted.
 func SetHTTPUserAgent(req *http.Request, instName string) {
-	if req == nil {
+	if req == nil || instName == "" {
 		return
 	}
 
 	//See if this User Agent is already in place
 
-	found := false
-	_, ok := req.Header[USERAGENT]
-
-	if ok {
-		for _, v := range req.Header[USERAGENT] {
-			if v == instName {
-				found = true
-				break
-			}
+	ua := req.Header.Get(USERAGENT)
+	for _, v := range strings.Fields(ua) {
+		if v == instName {
+			return
 		}
 	}
-
-	if !found {
-		req.Header.Add(USERAGENT, instName)
+	if ua == "" {
+		req.Header.Set(USERAGENT, instName)
+	} else {
+		req.Header.Set(USERAGENT, ua+" "+instName)
 	}
 }
 
@@ -98,6 +133,9 @@ func NewHTTPRequest(fullURL string) *HTTPRequest {
 	}
 }
 
+// Describe the request, e.g. for log messages.  Sensitive fields in the
+// payload are redacted, and a long payload is truncated, as they are by the
+// request's HTTPLogger.
 func (request *HTTPRequest) String() string {
 	return fmt.Sprintf(
 		"Context: %s, "+
@@ -108,16 +146,18 @@ func (request *HTTPRequest) String() string {
 			"Timeout: %d, "+
 			"SkipTLSVerify: %t, "+
 			"ExpectedStatusCode: %d, "+
-			"ContentType: %s",
+			"ContentType: %s, "+
+			"MaxResponseBytes: %d",
 		request.Context,
 		request.Method,
-		request.FullURL,
-		string(request.Payload),
+		redactURLString(request.FullURL),
+		request.httpLogger().RedactBody(request.Payload, request.ContentType),
 		request.Auth,
 		request.Timeout,
 		request.SkipTLSVerify,
 		request.ExpectedStatusCode,
-		request.ContentType)
+		request.ContentType,
+		request.MaxResponseBytes)
 }
 
 // HTTP basic authentication structure.
@@ -136,59 +176,344 @@ func (auth Auth) String() string {
 // Given a HTTPRequest this function will facilitate the desired operation using the retryablehttp package to gracefully
 // retry should the connection fail.
 func (request *HTTPRequest) DoHTTPAction() (payloadBytes []byte, err error) {
-	// Sanity check
-	if request.FullURL == "" {
-		err = fmt.Errorf("URL can not be empty")
+	resp, err := request.doHTTP(false)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	// Get the payload.
+	payloadBytes, readErr := ioutil.ReadAll(resp.Body)
+	if readErr != nil {
+		err = fmt.Errorf("unable to read response body: %w", readErr)
 		return
 	}
 
-	// Setup the common HTTP request stuff.
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: request.SkipTLSVerify},
+	return
+}
+
+// Same as DoHTTPAction, but instead of reading the whole response body into
+// memory it is handed back to the caller to be read as a stream.  This is
+// the one to use for large inventory dumps, firmware images and the like.
+//
+// The caller MUST Close() the returned body.  Close() drains whatever has not
+// been read yet (so the connection can be reused, just as
+// DrainAndCloseResponseBody does) and is safe to call more than once.
+//
+// If MaxResponseBytes is set, reading past that many bytes fails with an
+// error wrapping ErrHTTPResponseTooLarge, and a response that announces a
+// larger Content-Length fails up front without any of it being read.
+//
+//  body, err := request.DoHTTPActionStream()
+//  if err != nil {
+//      return err
+//  }
+//  defer body.Close()
+//  _, err = io.Copy(imageFile, body)
+func (request *HTTPRequest) DoHTTPActionStream() (body io.ReadCloser, err error) {
+	resp, err := request.doHTTP(true)
+	if err != nil {
+		return
+	}
+	body = resp.Body
+	return
+}
+
+// Same as DoHTTPActionStream, but hands back the whole http.Response so the
+// status code and headers can be looked at as well.  The caller MUST Close()
+// resp.Body, which behaves as described for DoHTTPActionStream.
+//
+// If the status code is not the expected one, the error is an
+// *HTTPStatusError and resp is nil.
+func (request *HTTPRequest) DoHTTPResponse() (*http.Response, error) {
+	resp, err := request.doHTTP(false)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Returns true if 'status' is the one expected by the request.
+func (request *HTTPRequest) isExpectedStatus(status int) bool {
+	if request.ExpectedStatusCode == 0 {
+		return status >= 200 && status <= 299
 	}
+	return status == request.ExpectedStatusCode
+}
+
+// Does the actual request for DoHTTPAction and friends.  On success the
+// response body has been replaced with one that enforces MaxResponseBytes
+// and drains on Close(), and must be closed by the caller.  On failure resp
+// is nil if no response was received, otherwise its body has already been
+// drained and closed.
+func (request *HTTPRequest) doHTTP(stream bool) (resp *http.Response, err error) {
+	// Sanity check
+	if request.FullURL == "" {
+		return nil, fmt.Errorf("URL can not be empty")
+	}
+
+	// Setup the common HTTP request stuff.  Timeouts are enforced through
+	// the context rather than the http.Client, so the error says which one
+	// was hit, and so the overall one covers retries and backoff.
 	client := retryablehttp.NewClient()
-	client.HTTPClient.Timeout = request.Timeout
-	client.HTTPClient.Transport = transport
+	client.HTTPClient.Transport = request.httpClient().transport(request.SkipTLSVerify)
+	if timeout := request.attemptTimeout(); timeout > 0 {
+		client.HTTPClient.Transport = &attemptTransport{
+			next: client.HTTPClient.Transport, timeout: timeout}
+	}
+
+	ctx := request.Context
+	if request.OverallTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, request.OverallTimeout, ErrRequestDeadline)
+		defer func() {
+			if err != nil {
+				cancel()
+			} else {
+				resp.Body = &cancelBody{ReadCloser: resp.Body, ctx: ctx, cancel: cancel,
+					limit: request.OverallTimeout}
+			}
+		}()
+	}
 
 	var req *retryablehttp.Request
+	var reqErr error
 
 	// If there's a payload, make sure to include it.
-	if request.Payload == nil {
-		req, _ = retryablehttp.NewRequest(request.Method, request.FullURL, nil)
+	body := request.Body
+	if body != nil && request.Payload != nil {
+		return nil, fmt.Errorf("only one of Payload and Body can be set")
+	}
+	body, payload, compressed, err := request.compressedBody(body)
+	if err != nil {
+		return nil, err
+	}
+	if body == nil && payload != nil && request.OnProgress != nil {
+		body = NewBytesBody(payload)
+	}
+	if body != nil {
+		req, reqErr = retryablehttp.NewRequest(request.Method, request.FullURL,
+			retryablehttp.ReaderFunc(body.readerFunc(request.OnProgress)))
+		if reqErr == nil && body.Length >= 0 {
+			req.ContentLength = body.Length
+		}
+	} else if payload == nil {
+		req, reqErr = retryablehttp.NewRequest(request.Method, request.FullURL, nil)
 	} else {
-		req, _ = retryablehttp.NewRequest(request.Method, request.FullURL, bytes.NewBuffer(request.Payload))
+		req, reqErr = retryablehttp.NewRequest(request.Method, request.FullURL, bytes.NewBuffer(payload))
+	}
+	if reqErr != nil {
+		return nil, fmt.Errorf("unable to create request: %w", reqErr)
 	}
 
 	// Set the context to the same we were given on the way in.
-	req = req.WithContext(request.Context)
+	req = req.WithContext(ctx)
 
 	req.Header.Set("Content-Type", request.ContentType)
+	if compressed {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	for name, vals := range request.Header {
+		req.Header.Del(name)
+		for _, val := range vals {
+			req.Header.Add(name, val)
+		}
+	}
 
+	// Say who we are, unless the caller already has.
+	if req.Header.Get(USERAGENT) == "" {
+		req.Header.Set(USERAGENT, request.httpClient().ServiceIdentity().UserAgent())
+	}
+
+	// Ask for a compressed response, unless the caller has their own ideas.
+	// It is decompressed below, so callers never see the difference.
+	decompress := false
+	if req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		decompress = true
+	}
+	if request.IfMatch != "" {
+		req.Header.Set("If-Match", request.IfMatch)
+	}
 	if request.Auth != nil {
 		req.SetBasicAuth(request.Auth.Username, request.Auth.Password)
 	}
 
+	// See if we can answer from the cache (below, once tracing and logging
+	// are set up), or get ready to revalidate what it has.
+	var cacheKey string
+	var cached *cacheEntry
+	var fresh bool
+	if request.Cache != nil && req.Method == http.MethodGet {
+		cacheKey = responseCacheKey(req.Request)
+		cached, fresh = request.Cache.lookup(cacheKey)
+		fresh = fresh && request.isExpectedStatus(http.StatusOK)
+		if cached != nil && !fresh {
+			cached.setConditions(req.Header)
+		}
+	}
+
+	// Pass on the ID of the request we're handling, if any.
+	if id := GetRequestID(ctx); id != "" && req.Header.Get(RequestIDHeader) == "" {
+		req.Header.Set(RequestIDHeader, id)
+	}
+
+	// If we're part of a trace, record this request as a client span and
+	// pass the trace on.
+	if _, ok := GetTraceContext(ctx); ok {
+		spanCtx, span := startSpan(ctx, "HTTP "+req.Method, SpanKindClient)
+		req = req.WithContext(spanCtx)
+		InjectTraceContext(req.Header, span.Context)
+		span.SetAttribute("http.method", req.Method)
+		span.SetAttribute("http.url", req.URL.Scheme+"://"+req.URL.Host+req.URL.Path)
+		defer func() {
+			if resp != nil {
+				span.SetAttribute("http.status_code", strconv.Itoa(resp.StatusCode))
+			}
+			span.SetError(err)
+			span.Finish()
+		}()
+	}
+
+	// Log the exchange, if debug logging is on.
+	if logger := request.httpLogger(); logger.Enabled(req.Context()) {
+		ctx, start := req.Context(), time.Now()
+		logger.logRequest(ctx, req.Request, request.Payload, request.Body != nil)
+		defer func() {
+			if err == nil {
+				logger.logResponseOnClose(ctx, req.Request, resp, start)
+				return
+			}
+			var body []byte
+			var statusErr *HTTPStatusError
+			if errors.As(err, &statusErr) {
+				body = statusErr.Body
+			}
+			logger.logResponse(ctx, req.Request, resp, body, start, err)
+		}()
+	}
+
+	if fresh {
+		return request.cachedResponse(cached, req.Request)
+	}
+
 	resp, doErr := client.Do(req)
-	defer DrainAndCloseResponseBody(resp)
 	if doErr != nil {
-		err = fmt.Errorf("unable to do request: %s", doErr)
-		return
+		DrainAndCloseResponseBody(resp)
+		if context.Cause(ctx) == ErrRequestDeadline {
+			return nil, fmt.Errorf("unable to do request: %w after %v: %w",
+				ErrRequestDeadline, request.OverallTimeout, doErr)
+		}
+		return nil, fmt.Errorf("unable to do request: %w", doErr)
+	}
+	unbounded := resp.ContentLength < 0
+	if decompress {
+		if err := decompressResponse(resp); err != nil {
+			DrainAndCloseResponseBody(resp)
+			return resp, err
+		}
+	}
+
+	if cached != nil && resp.StatusCode == http.StatusNotModified {
+		DrainAndCloseResponseBody(resp)
+		request.Cache.revalidated(cacheKey, resp.Header)
+		return request.cachedResponse(cached, req.Request)
 	}
 
 	// Make sure we get the status code we expect.
-	if resp.StatusCode != request.ExpectedStatusCode {
-		err = fmt.Errorf("received unexpected status code: %d", resp.StatusCode)
-		return
+	if !request.isExpectedStatus(resp.StatusCode) {
+		statusErr := &HTTPStatusError{StatusCode: resp.StatusCode, Header: resp.Header}
+		limit := int64(maxStatusErrorBodyBytes)
+		if request.MaxResponseBytes > 0 && request.MaxResponseBytes < limit {
+			limit = request.MaxResponseBytes
+		}
+		statusErr.Body, _ = io.ReadAll(io.LimitReader(resp.Body, limit))
+		DrainAndCloseResponseBody(resp)
+		return resp, statusErr
 	}
 
-	// Get the payload.
-	payloadBytes, readErr := ioutil.ReadAll(resp.Body)
-	if readErr != nil {
-		err = fmt.Errorf("unable to read response body: %s", readErr)
-		return
+	// No point reading (or draining) something we already know is too big.
+	if request.MaxResponseBytes > 0 && resp.ContentLength > request.MaxResponseBytes {
+		resp.Body.Close()
+		return resp, fmt.Errorf("%w: Content-Length %d, limit %d bytes",
+			ErrHTTPResponseTooLarge, resp.ContentLength, request.MaxResponseBytes)
 	}
 
-	return
+	resp.Body = &responseBody{body: resp.Body, limit: request.MaxResponseBytes}
+
+	if request.Cache != nil {
+		if cacheKey == "" && req.Method != http.MethodHead {
+			request.Cache.Invalidate(req.URL.String())
+		} else if resp.StatusCode == http.StatusOK {
+			request.Cache.miss()
+			// A stream, or a body of unknown length, is handed over as it
+			// comes rather than held back to be cached.
+			if !stream && !unbounded {
+				if err := request.Cache.storeResponse(cacheKey, req.URL.String(), resp); err != nil {
+					return resp, fmt.Errorf("unable to read response body: %w", err)
+				}
+			}
+		}
+	}
+	return resp, nil
+}
+
+// Response to 'req' from 'cached', subject to the same size limit as one
+// from the server.
+func (request *HTTPRequest) cachedResponse(cached *cacheEntry, req *http.Request) (*http.Response, error) {
+	resp := cached.response(req)
+	if request.MaxResponseBytes > 0 && resp.ContentLength > request.MaxResponseBytes {
+		resp.Body.Close()
+		return resp, fmt.Errorf("%w: Content-Length %d, limit %d bytes",
+			ErrHTTPResponseTooLarge, resp.ContentLength, request.MaxResponseBytes)
+	}
+	return resp, nil
+}
+
+// Response body handed out by doHTTP().  Enforces the size limit, if any,
+// and makes sure the underlying body is drained and closed exactly once.
+type responseBody struct {
+	body      io.ReadCloser
+	limit     int64 // 0 means no limit
+	count     int64
+	closeOnce sync.Once
+}
+
+func (b *responseBody) Read(p []byte) (int, error) {
+	if b.limit <= 0 {
+		n, err := b.body.Read(p)
+		b.count += int64(n)
+		return n, err
+	}
+	if b.count > b.limit {
+		return 0, b.tooLarge()
+	}
+	// Read at most one byte past the limit, which is enough to tell
+	// whether it was exceeded.
+	if room := b.limit - b.count + 1; int64(len(p)) > room {
+		p = p[:room]
+	}
+	n, err := b.body.Read(p)
+	b.count += int64(n)
+	if b.count > b.limit {
+		return n - int(b.count-b.limit), b.tooLarge()
+	}
+	return n, err
+}
+
+func (b *responseBody) tooLarge() error {
+	return fmt.Errorf("%w: limit %d bytes", ErrHTTPResponseTooLarge, b.limit)
+}
+
+// Drains (no further than the size limit) and closes the body.  Ok to call
+// more than once.
+func (b *responseBody) Close() error {
+	var err error
+	b.closeOnce.Do(func() {
+		_, _ = io.Copy(io.Discard, b)
+		err = b.body.Close()
+	})
+	return err
 }
 
 // Returns an interface for the response body for a given request by calling DoHTTPAction and unmarshaling.
@@ -220,6 +545,97 @@ func (request *HTTPRequest) GetBodyForHTTPRequest() (v interface{}, err error) {
 	return
 }
 
+// Streams a JSON array response through DecodeJSONArray, calling fn once per
+// array element, so large collections can be processed without holding the
+// whole response in memory.  See DecodeJSONArray for the meaning of 'key'.
+//
+//  request := base.NewHTTPRequest("http://cray-smd/hsm/v2/State/Components")
+//  err := request.StreamJSONArray("Components", func(item json.RawMessage) error {
+//      var comp base.Component
+//      if err := json.Unmarshal(item, &comp); err != nil {
+//          return err
+//      }
+//      return process(&comp)
+//  })
+func (request *HTTPRequest) StreamJSONArray(key string, fn func(item json.RawMessage) error) error {
+	body, err := request.DoHTTPActionStream()
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	return DecodeJSONArray(body, key, fn)
+}
+
+// Decode a JSON array from r one element at a time, calling fn with the raw
+// JSON of each element as it is read.
+//
+// If 'key' is the empty string, r must contain a top-level JSON array.
+// Otherwise r must contain a JSON object and the array is taken from its
+// top-level member named 'key' (exact match), e.g. "Members" for a Redfish
+// collection or "Components" for an HSM component list.  Other members are
+// skipped, and anything after the array is not read.  A null array is
+// treated the same as an empty one.
+//
+// If fn returns an error, decoding stops and that error is returned as-is.
+func DecodeJSONArray(r io.Reader, key string, fn func(item json.RawMessage) error) error {
+	dec := json.NewDecoder(r)
+
+	if key != "" {
+		if err := expectJSONDelim(dec, '{'); err != nil {
+			return err
+		}
+		for {
+			if !dec.More() {
+				return fmt.Errorf("unable to decode JSON array: no '%s' member found", key)
+			}
+			tok, err := dec.Token()
+			if err != nil {
+				return fmt.Errorf("unable to decode JSON array: %w", err)
+			}
+			if name, _ := tok.(string); name == key {
+				break
+			}
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return fmt.Errorf("unable to decode JSON array: %w", err)
+			}
+		}
+	}
+
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("unable to decode JSON array: %w", err)
+	}
+	if tok == nil {
+		return nil
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("unable to decode JSON array: expected '[', got %v", tok)
+	}
+	for dec.More() {
+		var item json.RawMessage
+		if err := dec.Decode(&item); err != nil {
+			return fmt.Errorf("unable to decode JSON array element: %w", err)
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return expectJSONDelim(dec, ']')
+}
+
+func expectJSONDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("unable to decode JSON array: %w", err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != want {
+		return fmt.Errorf("unable to decode JSON array: expected '%s', got %v", want, tok)
+	}
+	return nil
+}
+
 // Response bodies should always be drained and closed, else we leak resources
 // and fail to reuse network connections.
 
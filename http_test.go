@@ -1,6 +1,6 @@
 // MIT License
 //
-// (C) Copyright [2021] Hewlett Packard Enterprise Development LP
+// (C) Copyright [2021,2026] Hewlett Packard Enterprise Development LP
 //
 // Permission is hereby granted, free of charge, to any person obtaining a
 // copy of this software and associated documentation files (the "Software"),
@@ -23,8 +23,14 @@
 package base
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -76,4 +82,219 @@ func Test_SetHTTPUserAgent(t *testing.T) {
 		t.Errorf("%s key has wrong value, expected: '%s', got: '%s'",
 			USERAGENT, expval, hkey)
 	}
+
+	// Appended to an existing User-Agent, once, in a single header.
+	req.Header.Set(USERAGENT, "cray-smd/2.3.0")
+	SetHTTPUserAgent(req, expval)
+	SetHTTPUserAgent(req, expval)
+	if len(req.Header[USERAGENT]) != 1 || req.Header.Get(USERAGENT) != "cray-smd/2.3.0 "+expval {
+		t.Errorf("Expected a single '%s' header 'cray-smd/2.3.0 %s', got %v",
+			USERAGENT, expval, req.Header[USERAGENT])
+	}
+}
+
+func TestDoHTTPActionStream(t *testing.T) {
+	payload := strings.Repeat("0123456789", 1000)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(payload))
+	}))
+	defer srv.Close()
+
+	request := NewHTTPRequest(srv.URL)
+	body, err := request.DoHTTPActionStream()
+	if err != nil {
+		t.Fatalf("DoHTTPActionStream() failed: %v", err)
+	}
+	buf := make([]byte, 10)
+	if _, err := io.ReadFull(body, buf); err != nil {
+		t.Errorf("Partial read failed: %v", err)
+	}
+	if string(buf) != payload[:10] {
+		t.Errorf("Wrong data, expected: '%s', got: '%s'", payload[:10], string(buf))
+	}
+	// Close drains the rest, and a second Close is harmless.
+	if err := body.Close(); err != nil {
+		t.Errorf("Close() failed: %v", err)
+	}
+	if err := body.Close(); err != nil {
+		t.Errorf("Second Close() failed: %v", err)
+	}
+
+	request.ExpectedStatusCode = http.StatusCreated
+	if _, err := request.DoHTTPActionStream(); err == nil {
+		t.Errorf("Expected unexpected status code error, got none")
+	}
+}
+
+func TestMaxResponseBytes(t *testing.T) {
+	payload := strings.Repeat("x", 100)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/chunked" {
+			// No Content-Length, so the limit is only hit while reading.
+			w.Write([]byte(payload[:50]))
+			w.(http.Flusher).Flush()
+			w.Write([]byte(payload[50:]))
+			return
+		}
+		w.Write([]byte(payload))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		path    string
+		limit   int64
+		tooBig  bool
+		readLen int
+	}{
+		{"/", 0, false, 100},
+		{"/", 100, false, 100},
+		{"/", 99, true, 0},
+		{"/chunked", 100, false, 100},
+		{"/chunked", 60, true, 60},
+	}
+	for i, test := range tests {
+		request := NewHTTPRequest(srv.URL + test.path)
+		request.MaxResponseBytes = test.limit
+
+		body, err := request.DoHTTPActionStream()
+		if err == nil {
+			var data []byte
+			data, err = io.ReadAll(body)
+			body.Close()
+			if len(data) != test.readLen {
+				t.Errorf("Test %d: expected %d bytes, got %d", i, test.readLen, len(data))
+			}
+		}
+		if test.tooBig != errors.Is(err, ErrHTTPResponseTooLarge) {
+			t.Errorf("Test %d: expected too large: %t, got error: %v", i, test.tooBig, err)
+		}
+
+		_, err = request.DoHTTPAction()
+		if test.tooBig != errors.Is(err, ErrHTTPResponseTooLarge) {
+			t.Errorf("Test %d: DoHTTPAction expected too large: %t, got error: %v",
+				i, test.tooBig, err)
+		}
+	}
+}
+
+func TestDecodeJSONArray(t *testing.T) {
+	tests := []struct {
+		json   string
+		key    string
+		expect []string
+		fail   bool
+	}{
+		{`[1, "two", {"three": 3}]`, "", []string{`1`, `"two"`, `{"three": 3}`}, false},
+		{`[]`, "", []string{}, false},
+		{`null`, "", []string{}, false},
+		{`{"@odata.id": "/x", "Members": [{"a":1}, {"a":2}], "Members@odata.count": 2}`,
+			"Members", []string{`{"a":1}`, `{"a":2}`}, false},
+		{`{"Skip": {"Members": [1]}, "Members": [3]}`, "Members", []string{`3`}, false},
+		{`{"Components": null}`, "Components", []string{}, false},
+		{`{"Other": []}`, "Members", []string{}, true},
+		{`{"Members": {}}`, "Members", []string{}, true},
+		{`[1, 2`, "", []string{`1`, `2`}, true},
+		{`{"Members": [1]}`, "", []string{}, true},
+	}
+	for i, test := range tests {
+		got := []string{}
+		err := DecodeJSONArray(strings.NewReader(test.json), test.key,
+			func(item json.RawMessage) error {
+				got = append(got, string(item))
+				return nil
+			})
+		if test.fail != (err != nil) {
+			t.Errorf("Test %d: expected failure: %t, got error: %v", i, test.fail, err)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(test.expect) {
+			t.Errorf("Test %d: expected items %v, got %v", i, test.expect, got)
+		}
+	}
+
+	// Errors from the callback stop decoding and are returned unchanged.
+	stopErr := errors.New("stop")
+	count := 0
+	err := DecodeJSONArray(strings.NewReader(`[1, 2, 3]`), "",
+		func(item json.RawMessage) error {
+			count++
+			return stopErr
+		})
+	if err != stopErr || count != 1 {
+		t.Errorf("Expected callback error after 1 item, got %v after %d", err, count)
+	}
+}
+
+func TestStreamJSONArray(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"Components": [{"ID": "x0c0s0b0n0"}, {"ID": "x0c0s0b0n1"}]}`))
+	}))
+	defer srv.Close()
+
+	var ids []string
+	err := NewHTTPRequest(srv.URL).StreamJSONArray("Components",
+		func(item json.RawMessage) error {
+			var comp Component
+			if err := json.Unmarshal(item, &comp); err != nil {
+				return err
+			}
+			ids = append(ids, comp.ID)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("StreamJSONArray() failed: %v", err)
+	}
+	if fmt.Sprint(ids) != "[x0c0s0b0n0 x0c0s0b0n1]" {
+		t.Errorf("Unexpected component IDs: %v", ids)
+	}
+}
+
+func TestHTTPStatusError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Custom") != "yes" || r.Header.Get("Content-Type") != "text/plain" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Location", "/tasks/1")
+		if r.Method == "POST" {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.Header().Set("Content-Type", ProblemDetailContentType)
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"type":"about:blank","status":409}`))
+	}))
+	defer srv.Close()
+
+	request := NewHTTPRequest(srv.URL)
+	request.Header = http.Header{"X-Custom": {"yes"}, "Content-Type": {"text/plain"}}
+	_, err := request.DoHTTPAction()
+	statusErr, ok := err.(*HTTPStatusError)
+	if !ok {
+		t.Fatalf("Expected *HTTPStatusError, got %T: %v", err, err)
+	}
+	if statusErr.StatusCode != http.StatusConflict ||
+		statusErr.Header.Get("Location") != "/tasks/1" ||
+		string(statusErr.Body) != `{"type":"about:blank","status":409}` {
+		t.Errorf("Unexpected HTTPStatusError contents: %+v", statusErr)
+	}
+	if err.Error() != "received unexpected status code: 409" {
+		t.Errorf("Unexpected error message: %s", err)
+	}
+
+	// Any 2xx is fine when ExpectedStatusCode is 0.
+	request.Method = "POST"
+	request.ExpectedStatusCode = 0
+	resp, err := request.DoHTTPResponse()
+	if err != nil {
+		t.Fatalf("DoHTTPResponse() failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted || resp.Header.Get("Location") != "/tasks/1" {
+		t.Errorf("Unexpected response: %d %v", resp.StatusCode, resp.Header)
+	}
+	request.Method = "GET"
+	if resp, err := request.DoHTTPResponse(); resp != nil || err == nil {
+		t.Errorf("Expected nil response and error for 409, got %v, %v", resp, err)
+	}
 }
@@ -0,0 +1,273 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package base
+
+import (
+	"crypto/tls"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HTTPClient holds the HTTP transports used by HTTPRequest.  Requests that
+// share an HTTPClient share its connection pool and its TLS settings, so
+// those can be changed in one place (and at any time) for every request
+// a service makes.
+//
+// HTTPRequests with a nil Client use the shared client returned by
+// GetSharedHTTPClient().  A service that needs different settings for
+// some of its traffic can create its own with NewHTTPClient() and set it
+// in those requests.
+type HTTPClient struct {
+	mu        sync.RWMutex
+	tlsConfig *tls.Config     // nil means Go defaults (system CA roots)
+	secure    *http.Transport // verifies server certificates
+	insecure  *http.Transport // for HTTPRequest.SkipTLSVerify
+	override  http.RoundTripper
+	limiter   *HostLimiter
+	identity  *ServiceIdentity
+	options   TransportOptions
+	unix      *unixTransport // For http+unix URLs, shared by both transports
+	registry  *ServiceRegistry
+}
+
+var sharedHTTPClient = NewHTTPClient()
+var sharedHTTPClientLock sync.RWMutex
+
+// Create a new HTTPClient with default TLS settings.
+func NewHTTPClient() *HTTPClient {
+	c := new(HTTPClient)
+	c.SetTLSConfig(nil)
+	return c
+}
+
+// Returns the HTTPClient used by HTTPRequests that do not set their own.
+func GetSharedHTTPClient() *HTTPClient {
+	sharedHTTPClientLock.RLock()
+	defer sharedHTTPClientLock.RUnlock()
+	return sharedHTTPClient
+}
+
+// Replace the HTTPClient used by HTTPRequests that do not set their own.
+// A nil 'c' restores a default client.
+func SetSharedHTTPClient(c *HTTPClient) {
+	if c == nil {
+		c = NewHTTPClient()
+	}
+	sharedHTTPClientLock.Lock()
+	defer sharedHTTPClientLock.Unlock()
+	sharedHTTPClient = c
+}
+
+// Set the TLS configuration (trusted CAs, client certificates, etc.) for all
+// subsequent requests made with c.  'cfg' is copied, so later changes to it
+// have no effect.  A nil 'cfg' restores the defaults.
+//
+// Requests already in progress finish with the old settings and idle
+// connections made with them are closed, so no connection made with the old
+// settings is reused.  This is what allows certificates to be rotated
+// without restarting the service.
+func (c *HTTPClient) SetTLSConfig(cfg *tls.Config) {
+	var secureCfg *tls.Config
+	if cfg == nil {
+		secureCfg = &tls.Config{}
+	} else {
+		secureCfg = cfg.Clone()
+	}
+	insecureCfg := secureCfg.Clone()
+	insecureCfg.InsecureSkipVerify = true
+
+	c.mu.Lock()
+	if cfg != nil {
+		c.tlsConfig = secureCfg.Clone()
+	} else {
+		c.tlsConfig = nil
+	}
+	oldSecure, oldInsecure := c.secure, c.insecure
+	c.secure = newHTTPTransport(secureCfg, &c.options, c.unix)
+	c.insecure = newHTTPTransport(insecureCfg, &c.options, c.unix)
+	c.mu.Unlock()
+
+	if oldSecure != nil {
+		oldSecure.CloseIdleConnections()
+		oldInsecure.CloseIdleConnections()
+	}
+}
+
+// Set how c's transports reach servers: proxies, Unix sockets, dialing and
+// name resolution (see TransportOptions).  Like SetTLSConfig(), this
+// affects subsequent requests, and idle connections made with the old
+// options are closed.  Returns an error, and leaves c as it was, if 'opts'
+// are not valid.
+func (c *HTTPClient) SetTransportOptions(opts TransportOptions) error {
+	if _, err := opts.proxyFunc(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.options = opts
+	oldSecure, oldInsecure, oldUnix := c.secure, c.insecure, c.unix
+	c.unix = nil
+	if len(opts.UnixSockets) > 0 {
+		c.unix = newUnixTransport(opts.UnixSockets)
+	}
+	c.secure = newHTTPTransport(oldSecure.TLSClientConfig.Clone(), &c.options, c.unix)
+	c.insecure = newHTTPTransport(oldInsecure.TLSClientConfig.Clone(), &c.options, c.unix)
+	c.mu.Unlock()
+
+	// http.Transport.CloseIdleConnections() doesn't reach registered
+	// protocols, so the Unix socket transport needs closing too.
+	oldSecure.CloseIdleConnections()
+	oldInsecure.CloseIdleConnections()
+	if oldUnix != nil {
+		oldUnix.CloseIdleConnections()
+	}
+	return nil
+}
+
+// Returns a copy of the TLS configuration set with SetTLSConfig(), or nil
+// if the defaults are in use.
+func (c *HTTPClient) TLSConfig() *tls.Config {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.tlsConfig == nil {
+		return nil
+	}
+	return c.tlsConfig.Clone()
+}
+
+// Use 'rt' for every request made with c instead of c's own transports,
+// e.g. to substitute a recording or replaying transport in tests (see the
+// hmstest package).  TLS settings are then up to 'rt'.  A nil 'rt' goes back
+// to c's own transports.
+func (c *HTTPClient) SetTransport(rt http.RoundTripper) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.override = rt
+}
+
+// Limit the requests made with c to each host with 'l' (see HostLimiter).
+// A nil 'l' removes the limits.
+func (c *HTTPClient) SetHostLimiter(l *HostLimiter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.limiter = l
+}
+
+// Send requests made with c to services registered in 'r' to their
+// endpoints, with failover (see ServiceRegistry).  A nil 'r' removes the
+// registry.
+func (c *HTTPClient) SetServiceRegistry(r *ServiceRegistry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.registry = r
+}
+
+// Identify requests made with c as coming from 'id', in their User-Agent
+// header (unless they set their own).  A nil 'id' means the default (see
+// GetDefaultServiceIdentity()).
+func (c *HTTPClient) SetServiceIdentity(id *ServiceIdentity) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.identity = id
+}
+
+// Returns the ServiceIdentity requests made with c are identified by.
+func (c *HTTPClient) ServiceIdentity() *ServiceIdentity {
+	c.mu.RLock()
+	id := c.identity
+	c.mu.RUnlock()
+	if id == nil {
+		return GetDefaultServiceIdentity()
+	}
+	return id
+}
+
+// Close any idle connections held by c.  Connections in use are not
+// affected.
+func (c *HTTPClient) CloseIdleConnections() {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	c.secure.CloseIdleConnections()
+	c.insecure.CloseIdleConnections()
+	if c.unix != nil {
+		c.unix.CloseIdleConnections()
+	}
+	if closer, ok := c.override.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// Transport to use for a request, depending on whether it skips TLS
+// verification.
+func (c *HTTPClient) transport(skipTLSVerify bool) http.RoundTripper {
+	rt := c.directTransport(skipTLSVerify)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.registry != nil {
+		rt = c.registry.RoundTripper(rt)
+	}
+	return rt
+}
+
+// Same as transport(), but without any ServiceRegistry.
+func (c *HTTPClient) directTransport(skipTLSVerify bool) http.RoundTripper {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var rt http.RoundTripper
+	switch {
+	case c.override != nil:
+		rt = c.override
+	case skipTLSVerify:
+		rt = c.insecure
+	default:
+		rt = c.secure
+	}
+	if c.limiter != nil {
+		rt = c.limiter.RoundTripper(rt)
+	}
+	return rt
+}
+
+func newHTTPTransport(cfg *tls.Config, opts *TransportOptions, unix *unixTransport) *http.Transport {
+	proxy, _ := opts.proxyFunc()
+	t := &http.Transport{
+		Proxy:               proxy,
+		DialContext:         opts.dialFunc(),
+		TLSClientConfig:     cfg,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	if unix != nil {
+		t.RegisterProtocol(UnixSocketScheme, unix)
+	}
+	return t
+}
+
+// HTTPClient to use for request.
+func (request *HTTPRequest) httpClient() *HTTPClient {
+	if request.Client != nil {
+		return request.Client
+	}
+	return GetSharedHTTPClient()
+}
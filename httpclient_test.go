@@ -0,0 +1,93 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package base
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSharedHTTPClient(t *testing.T) {
+	orig := GetSharedHTTPClient()
+	defer SetSharedHTTPClient(orig)
+
+	c := NewHTTPClient()
+	SetSharedHTTPClient(c)
+	if GetSharedHTTPClient() != c {
+		t.Errorf("SetSharedHTTPClient() didn't replace the shared client")
+	}
+	SetSharedHTTPClient(nil)
+	if GetSharedHTTPClient() == nil || GetSharedHTTPClient() == c {
+		t.Errorf("SetSharedHTTPClient(nil) didn't restore a default client")
+	}
+
+	request := NewHTTPRequest("http://example.com")
+	if request.httpClient() != GetSharedHTTPClient() {
+		t.Errorf("Request without a Client doesn't use the shared client")
+	}
+	request.Client = c
+	if request.httpClient() != c {
+		t.Errorf("Request with a Client doesn't use it")
+	}
+}
+
+func TestHTTPClientTLSConfig(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	c := NewHTTPClient()
+	if c.TLSConfig() != nil {
+		t.Errorf("New client should have default TLS config")
+	}
+	request := NewHTTPRequest(srv.URL)
+	request.Client = c
+
+	// Self-signed test server isn't trusted...
+	if _, err := request.DoHTTPAction(); err == nil {
+		t.Errorf("Expected certificate error from untrusted server")
+	}
+	// ...unless told to skip verification...
+	request.SkipTLSVerify = true
+	if _, err := request.DoHTTPAction(); err != nil {
+		t.Errorf("SkipTLSVerify request failed: %v", err)
+	}
+	// ...or the server's CA is added.
+	request.SkipTLSVerify = false
+	cfg := &tls.Config{RootCAs: srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs}
+	c.SetTLSConfig(cfg)
+	if _, err := request.DoHTTPAction(); err != nil {
+		t.Errorf("Request with trusted CA failed: %v", err)
+	}
+
+	// Config is copied both ways.
+	cfg.ServerName = "changed"
+	if c.TLSConfig().ServerName != "" {
+		t.Errorf("SetTLSConfig() didn't copy its argument")
+	}
+	c.TLSConfig().ServerName = "changed"
+	if c.TLSConfig().ServerName != "" {
+		t.Errorf("TLSConfig() didn't return a copy")
+	}
+}
@@ -0,0 +1,367 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package base
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// What redacted values are replaced with.
+const Redacted = "<REDACTED>"
+
+// Default longest body logged by an HTTPLogger.
+const DefaultLogBodyBytes = 4096
+
+// Headers an HTTPLogger redacts by default.
+var DefaultRedactHeaders = []string{
+	"Authorization", "Proxy-Authorization", "X-Auth-Token", "Cookie", "Set-Cookie",
+}
+
+// JSON fields (and form values) an HTTPLogger redacts by default.  A field
+// is redacted if its name, lower-cased, contains any of these.
+var DefaultRedactFields = []string{
+	"password", "passwd", "token", "secret", "credential", "apikey", "api_key", "privatekey", "private_key",
+}
+
+// Logs HTTP exchanges made by HTTPRequests, for debugging, without leaking
+// credentials: the values of sensitive headers and JSON body fields are
+// redacted, as is any password in the URL, and bodies are truncated.
+//
+// Nothing is logged unless Logger is enabled for Level, which for the
+// default HTTPLogger (slog.Default() at LevelDebug) means turning on debug
+// logging, and nothing is captured for logging either, so it costs next to
+// nothing when off.
+//
+// Each request is logged once ("HTTP request", however many times it is
+// retried) and so is its outcome ("HTTP response", once its body has been
+// closed, so it can include the start of the body).
+type HTTPLogger struct {
+	Logger        *slog.Logger // nil for slog.Default()
+	Level         slog.Level   // Level to log at
+	RedactHeaders []string     // Header names
+	RedactFields  []string     // Lower case JSON field name fragments
+	MaxBodyBytes  int          // Longest body logged, -1 to log no bodies
+}
+
+// Create an HTTPLogger logging to 'logger' (slog.Default() if nil) at
+// LevelDebug, with the default redactions.
+func NewHTTPLogger(logger *slog.Logger) *HTTPLogger {
+	return &HTTPLogger{
+		Logger:        logger,
+		Level:         slog.LevelDebug,
+		RedactHeaders: append([]string{}, DefaultRedactHeaders...),
+		RedactFields:  append([]string{}, DefaultRedactFields...),
+		MaxBodyBytes:  DefaultLogBodyBytes,
+	}
+}
+
+var defaultHTTPLogger = NewHTTPLogger(nil)
+var defaultHTTPLoggerLock sync.RWMutex
+
+// Returns the HTTPLogger used by HTTPRequests that do not set their own.
+func GetDefaultHTTPLogger() *HTTPLogger {
+	defaultHTTPLoggerLock.RLock()
+	defer defaultHTTPLoggerLock.RUnlock()
+	return defaultHTTPLogger
+}
+
+// Replace the HTTPLogger used by HTTPRequests that do not set their own.
+// A nil 'l' restores the default.
+func SetDefaultHTTPLogger(l *HTTPLogger) {
+	if l == nil {
+		l = NewHTTPLogger(nil)
+	}
+	defaultHTTPLoggerLock.Lock()
+	defer defaultHTTPLoggerLock.Unlock()
+	defaultHTTPLogger = l
+}
+
+func (l *HTTPLogger) logger() *slog.Logger {
+	return loggerOrDefault(l.Logger)
+}
+
+// Returns true if exchanges would be logged.
+func (l *HTTPLogger) Enabled(ctx context.Context) bool {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return l.logger().Enabled(ctx, l.Level)
+}
+
+// Returns a copy of 'h' with sensitive header values redacted.
+func (l *HTTPLogger) RedactHeader(h http.Header) http.Header {
+	out := h.Clone()
+	for _, name := range l.RedactHeaders {
+		if vals, ok := out[http.CanonicalHeaderKey(name)]; ok {
+			for i := range vals {
+				vals[i] = Redacted
+			}
+		}
+	}
+	return out
+}
+
+// Returns true if a field called 'name' is to be redacted.
+func (l *HTTPLogger) sensitiveField(name string) bool {
+	name = strings.ToLower(name)
+	for _, f := range l.RedactFields {
+		if strings.Contains(name, f) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns 'body' as it should be logged: with sensitive fields redacted if
+// it is JSON or a form, and truncated to MaxBodyBytes.  Bodies of other
+// types can't be redacted, so only their length is logged.
+func (l *HTTPLogger) RedactBody(body []byte, contentType string) string {
+	if len(body) == 0 || l.MaxBodyBytes < 0 {
+		return ""
+	}
+	ct := strings.ToLower(contentType)
+	var out string
+	switch {
+	case strings.Contains(ct, "json") || ct == "":
+		var v interface{}
+		if err := json.Unmarshal(body, &v); err != nil {
+			if ct == "" {
+				return fmt.Sprintf("[%d bytes]", len(body))
+			}
+			// Possibly truncated; redact what we can see.
+			out = l.redactJSONText(string(body))
+			break
+		}
+		var redacted bytes.Buffer
+		enc := json.NewEncoder(&redacted)
+		enc.SetEscapeHTML(false)
+		enc.Encode(l.redactJSON(v))
+		out = strings.TrimSuffix(redacted.String(), "\n")
+	case strings.HasPrefix(ct, "application/x-www-form-urlencoded"):
+		vals, err := url.ParseQuery(string(body))
+		if err != nil {
+			return fmt.Sprintf("[%d bytes]", len(body))
+		}
+		for key := range vals {
+			if l.sensitiveField(key) {
+				vals[key] = []string{Redacted}
+			}
+		}
+		out = vals.Encode()
+	case strings.HasPrefix(ct, "text/"):
+		out = string(body)
+	default:
+		return fmt.Sprintf("[%d bytes of %s]", len(body), contentType)
+	}
+
+	max := l.MaxBodyBytes
+	if max == 0 {
+		max = DefaultLogBodyBytes
+	}
+	if len(out) > max {
+		out = out[:max] + "...(truncated)"
+	}
+	return out
+}
+
+func (l *HTTPLogger) redactJSON(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for key, sub := range val {
+			if l.sensitiveField(key) {
+				val[key] = Redacted
+			} else {
+				val[key] = l.redactJSON(sub)
+			}
+		}
+	case []interface{}:
+		for i := range val {
+			val[i] = l.redactJSON(val[i])
+		}
+	}
+	return v
+}
+
+// Redact "field": "value" pairs in text that isn't valid JSON, e.g. the
+// start of a truncated body.  Values that aren't strings are left alone.
+func (l *HTTPLogger) redactJSONText(s string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(s, `"`)
+		if i < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		b.WriteString(s[:i])
+		s = s[i:]
+		name, rest, ok := cutJSONString(s)
+		b.WriteString(s[:len(s)-len(rest)])
+		s = rest
+		if !ok || !l.sensitiveField(name) {
+			continue
+		}
+		colon := strings.TrimLeft(s, " \t\r\n")
+		if !strings.HasPrefix(colon, ":") {
+			continue
+		}
+		val := strings.TrimLeft(colon[1:], " \t\r\n")
+		if _, after, ok := cutJSONString(val); ok {
+			b.WriteString(s[:len(s)-len(val)])
+			b.WriteString(`"` + Redacted + `"`)
+			s = after
+		} else if strings.HasPrefix(val, `"`) {
+			// Cut off mid-value.
+			b.WriteString(s[:len(s)-len(val)])
+			b.WriteString(`"` + Redacted)
+			return b.String()
+		}
+	}
+}
+
+// Split the JSON string at the start of 's' from the rest.  Returns its
+// (raw) contents and ok = false if it is not terminated.
+func cutJSONString(s string) (contents, rest string, ok bool) {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return s[1:i], s[i+1:], true
+		}
+	}
+	return s[1:], "", false
+}
+
+// Returns 'u' with any password replaced.
+func redactURL(u *url.URL) string {
+	return u.Redacted()
+}
+
+func redactURLString(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return s
+	}
+	return u.Redacted()
+}
+
+// HTTPLogger to use for request.
+func (request *HTTPRequest) httpLogger() *HTTPLogger {
+	if request.Logger != nil {
+		return request.Logger
+	}
+	return GetDefaultHTTPLogger()
+}
+
+func (l *HTTPLogger) logRequest(ctx context.Context, req *http.Request, body []byte, streamed bool) {
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("url", redactURL(req.URL)),
+		slog.Any("header", l.RedactHeader(req.Header)),
+		slog.String("request_id", GetRequestID(ctx)),
+	}
+	if streamed {
+		attrs = append(attrs, slog.String("body", "[streamed]"))
+	} else if b := l.RedactBody(body, req.Header.Get("Content-Type")); b != "" {
+		attrs = append(attrs, slog.String("body", b))
+	}
+	l.logger().LogAttrs(ctx, l.Level, "HTTP request", attrs...)
+}
+
+func (l *HTTPLogger) logResponse(ctx context.Context, req *http.Request, resp *http.Response, body []byte, start time.Time, err error) {
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("url", redactURL(req.URL)),
+		slog.Duration("duration", time.Since(start)),
+		slog.String("request_id", GetRequestID(ctx)),
+	}
+	if resp != nil {
+		attrs = append(attrs,
+			slog.Int("status", resp.StatusCode),
+			slog.Any("header", l.RedactHeader(resp.Header)))
+		if b := l.RedactBody(body, resp.Header.Get("Content-Type")); b != "" {
+			attrs = append(attrs, slog.String("body", b))
+		}
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	l.logger().LogAttrs(ctx, l.Level, "HTTP response", attrs...)
+}
+
+// Response body that keeps the start of what is read, and logs the
+// response when closed.
+type loggingBody struct {
+	io.ReadCloser
+	buf     bytes.Buffer
+	max     int
+	readErr error
+	logOnce sync.Once
+	log     func(body []byte, err error)
+}
+
+func (b *loggingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if room := b.max - b.buf.Len(); room > 0 {
+		b.buf.Write(p[:min(n, room)])
+	}
+	if err != nil && err != io.EOF {
+		b.readErr = err
+	}
+	return n, err
+}
+
+func (b *loggingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.logOnce.Do(func() { b.log(b.buf.Bytes(), b.readErr) })
+	return err
+}
+
+// Wrap 'resp.Body' so the response is logged once it is closed.
+func (l *HTTPLogger) logResponseOnClose(ctx context.Context, req *http.Request, resp *http.Response, start time.Time) {
+	max := l.MaxBodyBytes
+	if max == 0 {
+		max = DefaultLogBodyBytes
+	}
+	if max < 0 {
+		max = 0
+	}
+	// A little extra, so truncated JSON is recognizable as such.
+	resp.Body = &loggingBody{
+		ReadCloser: resp.Body,
+		max:        max + 1,
+		log: func(body []byte, err error) {
+			l.logResponse(ctx, req, resp, body, start, err)
+		},
+	}
+}
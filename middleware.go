@@ -0,0 +1,349 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package base
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////
+//
+// HTTP server middleware
+//
+// Composable http.Handler wrappers so that all HMS services handle request
+// IDs, logging, panics and request bodies the same way.  Most services will
+// just want HMSMiddleware(), which combines them in the right order:
+//
+//  router := mux.NewRouter()
+//  ...
+//  handler := base.HMSMiddleware(logger, 1<<20)(router)
+//  http.ListenAndServe(":27779", handler)
+//
+////////////////////////////////////////////////////////////////////////////
+
+// A Middleware wraps an http.Handler to add behavior before and/or after it.
+type Middleware func(http.Handler) http.Handler
+
+// Wrap 'h' in each of 'mw'.  The first Middleware listed is the outermost,
+// i.e. it sees the request first and the response last.
+func ChainMiddleware(h http.Handler, mw ...Middleware) http.Handler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
+
+// The standard HMS server middleware, outermost first: RequestIDMiddleware,
+// TraceMiddleware, AccessLogMiddleware, RecoveryMiddleware, UserAgentMiddleware,
+// MaxBodyMiddleware (skipped if 'maxBodyBytes' is 0 or less) and
+// DrainBodyMiddleware.  A nil 'logger' means slog.Default().
+func HMSMiddleware(logger *slog.Logger, maxBodyBytes int64) Middleware {
+	mw := []Middleware{
+		RequestIDMiddleware,
+		TraceMiddleware,
+		AccessLogMiddleware(logger),
+		RecoveryMiddleware(logger),
+		UserAgentMiddleware,
+	}
+	if maxBodyBytes > 0 {
+		mw = append(mw, MaxBodyMiddleware(maxBodyBytes))
+	}
+	mw = append(mw, DrainBodyMiddleware)
+
+	return func(h http.Handler) http.Handler {
+		return ChainMiddleware(h, mw...)
+	}
+}
+
+type contextKey string
+
+const requestIDContextKey = contextKey("requestID")
+const userAgentContextKey = contextKey("userAgent")
+
+////////////////////////////////////////////////////////////////////////////
+// Request IDs
+////////////////////////////////////////////////////////////////////////////
+
+// Header carrying the ID of a request, both into and out of HMS services.
+const RequestIDHeader = "X-Request-ID"
+
+// Longest incoming request ID that will be accepted as-is.
+const maxRequestIDLen = 128
+
+// Returns a copy of 'ctx' carrying request ID 'id'.  HTTPRequests made with
+// such a context pass the ID on in their RequestIDHeader, which is how IDs
+// follow a request from one HMS service to the next.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey, id)
+}
+
+// Returns the request ID carried by 'ctx', or the empty string if there is
+// none.  In a handler wrapped by RequestIDMiddleware, this is the ID of the
+// request being handled.
+func GetRequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDContextKey).(string)
+	return id
+}
+
+// Generate a new random request ID.
+func NewRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Returns true if 'id' is an acceptable request ID.  These are echoed back
+// in headers and used as a ProblemDetails Instance, so only unreserved URI
+// characters are allowed.
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for _, c := range id {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '.' || c == '_' || c == '~') {
+			return false
+		}
+	}
+	return true
+}
+
+// Use the request ID from the incoming RequestIDHeader, or generate a new one
+// if it is missing or invalid, and make it available to the handler via
+// GetRequestID(r.Context()).  The ID is also set in the response header.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !isValidRequestID(id) {
+			id = NewRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(ContextWithRequestID(r.Context(), id)))
+	})
+}
+
+////////////////////////////////////////////////////////////////////////////
+// Access logging
+////////////////////////////////////////////////////////////////////////////
+
+// Wraps an http.ResponseWriter to record what was sent.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if !rec.wroteHeader {
+		rec.WriteHeader(http.StatusOK)
+	}
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += int64(n)
+	return n, err
+}
+
+// Let http.ResponseController get at Flush() etc. on the original.
+func (rec *responseRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+func (rec *responseRecorder) Flush() {
+	if !rec.wroteHeader {
+		rec.WriteHeader(http.StatusOK)
+	}
+	http.NewResponseController(rec.ResponseWriter).Flush()
+}
+
+// Returns a responseRecorder for 'w', reusing 'w' if it already is one.
+func recordResponse(w http.ResponseWriter) *responseRecorder {
+	if rec, ok := w.(*responseRecorder); ok {
+		return rec
+	}
+	return &responseRecorder{ResponseWriter: w}
+}
+
+// Log one structured line per request to 'logger' (slog.Default() if nil)
+// once it has been handled, with the method, path, status, response size,
+// duration, remote address, user agent (and the calling service parsed from
+// it), request ID and trace ID.
+func AccessLogMiddleware(logger *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := recordResponse(w)
+			defer func() {
+				status := rec.status
+				if !rec.wroteHeader {
+					status = http.StatusOK
+				}
+				traceID := ""
+				if tc, ok := GetTraceContext(r.Context()); ok {
+					traceID = tc.TraceIDString()
+				}
+				caller := ""
+				if id, err := ParseUserAgent(r.UserAgent()); err == nil {
+					caller = id.Service
+				}
+				loggerOrDefault(logger).LogAttrs(r.Context(), slog.LevelInfo, "request",
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.Int("status", status),
+					slog.Int64("bytes", rec.bytes),
+					slog.Duration("duration", time.Since(start)),
+					slog.String("remote", r.RemoteAddr),
+					slog.String("user_agent", r.UserAgent()),
+					slog.String("caller", caller),
+					slog.String("request_id", GetRequestID(r.Context())),
+					slog.String("trace_id", traceID),
+				)
+			}()
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
+
+func loggerOrDefault(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return slog.Default()
+	}
+	return logger
+}
+
+////////////////////////////////////////////////////////////////////////////
+// Panic recovery
+////////////////////////////////////////////////////////////////////////////
+
+// Recover from panics in the handler, logging them (with a stack trace) to
+// 'logger' (slog.Default() if nil) and responding with a 500 Internal Server
+// Error ProblemDetails, rather than letting net/http drop the connection.
+// The ProblemDetails Instance is the request ID, if there is one, so the
+// response can be matched up with the log.
+//
+// If the handler had already started its response, it can't be replaced, so
+// the connection is aborted instead.  Panics with http.ErrAbortHandler are
+// passed on, as that is what they are meant to do.
+func RecoveryMiddleware(logger *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := recordResponse(w)
+			defer func() {
+				val := recover()
+				if val == nil {
+					return
+				}
+				if val == http.ErrAbortHandler {
+					panic(val)
+				}
+				id := GetRequestID(r.Context())
+				loggerOrDefault(logger).LogAttrs(r.Context(), slog.LevelError, "panic in handler",
+					slog.String("panic", fmt.Sprint(val)),
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.String("request_id", id),
+					slog.String("stack", string(debug.Stack())),
+				)
+				if rec.wroteHeader {
+					panic(http.ErrAbortHandler)
+				}
+				p := NewProblemDetailsStatus("An unexpected error occurred", http.StatusInternalServerError)
+				p.Instance = id
+				SendProblemDetails(rec, p, 0)
+			}()
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////
+// Request bodies
+////////////////////////////////////////////////////////////////////////////
+
+// Limit request bodies to 'maxBytes'.  Requests whose Content-Length is
+// already too large get a 413 Request Entity Too Large ProblemDetails without
+// the handler being called.  Otherwise, reading more than 'maxBytes' from the
+// body fails with an *http.MaxBytesError, which handlers can turn into a 413
+// themselves.
+func MaxBodyMiddleware(maxBytes int64) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > maxBytes {
+				SendProblemDetailsGeneric(w, http.StatusRequestEntityTooLarge,
+					fmt.Sprintf("Request body too large, limit is %d bytes", maxBytes))
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Drain and close the request body (see DrainAndCloseRequestBody()) once the
+// handler is done with it, so handlers don't have to.
+func DrainBodyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer DrainAndCloseRequestBody(r)
+		next.ServeHTTP(w, r)
+	})
+}
+
+////////////////////////////////////////////////////////////////////////////
+// User-Agent
+////////////////////////////////////////////////////////////////////////////
+
+// Make the caller's User-Agent available to the handler via
+// GetUserAgent(r.Context()), e.g. to attribute traffic to calling services.
+func UserAgentMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), userAgentContextKey, r.UserAgent())
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Returns the User-Agent captured by UserAgentMiddleware, or the empty
+// string if there is none.
+func GetUserAgent(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	ua, _ := ctx.Value(userAgentContextKey).(string)
+	return ua
+}
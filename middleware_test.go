@@ -0,0 +1,255 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package base
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestChainMiddleware(t *testing.T) {
+	var order []string
+	mw := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	h := ChainMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler")
+	}), mw("first"), mw("second"))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if strings.Join(order, ",") != "first,second,handler" {
+		t.Errorf("Wrong middleware order: %v", order)
+	}
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var gotID string
+	h := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotID = GetRequestID(r.Context())
+	}))
+
+	tests := []struct {
+		incoming string
+		keep     bool
+	}{
+		{"", false},
+		{"abc-123_x.y~z", true},
+		{"bad id", false},
+		{"bad\nid", false},
+		{strings.Repeat("a", maxRequestIDLen+1), false},
+	}
+	for i, test := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		if test.incoming != "" {
+			req.Header.Set(RequestIDHeader, test.incoming)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		if gotID == "" || !isValidRequestID(gotID) {
+			t.Errorf("Test %d: bad request ID in context: '%s'", i, gotID)
+		}
+		if test.keep != (gotID == test.incoming) {
+			t.Errorf("Test %d: incoming ID '%s', got '%s'", i, test.incoming, gotID)
+		}
+		if w.Header().Get(RequestIDHeader) != gotID {
+			t.Errorf("Test %d: response header '%s' doesn't match ID '%s'",
+				i, w.Header().Get(RequestIDHeader), gotID)
+		}
+	}
+
+	if GetRequestID(nil) != "" {
+		t.Errorf("Expected no request ID from nil context")
+	}
+}
+
+func TestRequestIDPropagation(t *testing.T) {
+	var gotID string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotID = r.Header.Get(RequestIDHeader)
+	}))
+	defer srv.Close()
+
+	request := NewHTTPRequest(srv.URL)
+	if _, err := request.DoHTTPAction(); err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if gotID != "" {
+		t.Errorf("Expected no request ID header, got '%s'", gotID)
+	}
+
+	request.Context = ContextWithRequestID(request.Context, "upstream-id")
+	if _, err := request.DoHTTPAction(); err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if gotID != "upstream-id" {
+		t.Errorf("Expected request ID 'upstream-id', got '%s'", gotID)
+	}
+}
+
+func TestAccessLogMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	h := ChainMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello"))
+	}), RequestIDMiddleware, AccessLogMiddleware(logger))
+
+	req := httptest.NewRequest("POST", "/hsm/v2/State/Components", nil)
+	req.Header.Set("User-Agent", "test-agent")
+	req.Header.Set(RequestIDHeader, "log-id")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Can't decode log entry '%s': %v", buf.String(), err)
+	}
+	expect := map[string]interface{}{
+		"method":     "POST",
+		"path":       "/hsm/v2/State/Components",
+		"status":     float64(http.StatusCreated),
+		"bytes":      float64(5),
+		"user_agent": "test-agent",
+		"caller":     "test-agent",
+		"request_id": "log-id",
+	}
+	for key, val := range expect {
+		if entry[key] != val {
+			t.Errorf("Log entry '%s' expected %v, got %v", key, val, entry[key])
+		}
+	}
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	h := HMSMiddleware(logger, 0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("something broke")
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "panic-id")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", w.Code)
+	}
+	if w.Header().Get("Content-Type") != ProblemDetailContentType {
+		t.Errorf("Expected content type '%s', got '%s'",
+			ProblemDetailContentType, w.Header().Get("Content-Type"))
+	}
+	var p ProblemDetails
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatalf("Can't decode ProblemDetails: %v", err)
+	}
+	if p.Status != http.StatusInternalServerError || p.Instance != "panic-id" {
+		t.Errorf("Unexpected ProblemDetails: %+v", p)
+	}
+	if !strings.Contains(buf.String(), "something broke") ||
+		!strings.Contains(buf.String(), "status=500") {
+		t.Errorf("Panic and/or 500 not logged: %s", buf.String())
+	}
+
+	// Once the response has started, the connection has to be aborted.
+	h = RecoveryMiddleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		panic("too late")
+	}))
+	defer func() {
+		if val := recover(); val != http.ErrAbortHandler {
+			t.Errorf("Expected http.ErrAbortHandler panic, got %v", val)
+		}
+	}()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
+
+func TestBodyMiddleware(t *testing.T) {
+	var readErr error
+	h := ChainMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = io.ReadAll(r.Body)
+	}), DrainBodyMiddleware, MaxBodyMiddleware(10))
+
+	// Known to be too large up front.
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/", strings.NewReader(strings.Repeat("x", 11))))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413, got %d", w.Code)
+	}
+
+	// Only found to be too large when read.
+	req := httptest.NewRequest("POST", "/", strings.NewReader(strings.Repeat("x", 11)))
+	req.ContentLength = -1
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if _, ok := readErr.(*http.MaxBytesError); !ok {
+		t.Errorf("Expected *http.MaxBytesError, got %v", readErr)
+	}
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/", strings.NewReader("ok")))
+	if readErr != nil {
+		t.Errorf("Unexpected error reading small body: %v", readErr)
+	}
+
+	// Bodies the handler ignores are drained and closed behind it.
+	body := &testBody{Reader: strings.NewReader("unread")}
+	h = DrainBodyMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/", body))
+	if body.Len() != 0 || !body.closed {
+		t.Errorf("Request body not drained and closed, %d bytes left, closed: %t",
+			body.Len(), body.closed)
+	}
+}
+
+type testBody struct {
+	*strings.Reader
+	closed bool
+}
+
+func (b *testBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestUserAgentMiddleware(t *testing.T) {
+	var ua string
+	h := UserAgentMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ua = GetUserAgent(r.Context())
+	}))
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("User-Agent", "cray-smd/2.0")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if ua != "cray-smd/2.0" {
+		t.Errorf("Expected User-Agent 'cray-smd/2.0', got '%s'", ua)
+	}
+}
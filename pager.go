@@ -0,0 +1,402 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package base
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How a collection API splits its results into pages.
+type PageStyle int
+
+const (
+	PageOffset   PageStyle = 0 // Offset and limit query parameters
+	PageCursor   PageStyle = 1 // Each page has a cursor to pass to get the next
+	PageNextLink PageStyle = 2 // Each page has a link to the next, in its body or Link header
+)
+
+// Default page size for PageOffset.
+const DefaultPageSize = 100
+
+// How to page through a collection API, for a PageIterator.  The zero value
+// is offset/limit paging of a JSON array, 100 items at a time.
+type Pagination struct {
+	Style       PageStyle
+	ItemsKey    string // Member of each page holding the items, "" if the page is an array
+	PageSize    int    // Items to ask for per page, 0 for the default (PageOffset) or the service's (others)
+	OffsetParam string // Query parameter for the offset, default "offset"
+	LimitParam  string // Query parameter for the page size, default "limit"
+	CursorParam string // Query parameter to pass the cursor in, default "cursor"
+	NextKey     string // Member holding the next cursor or link ("" for the Link header with PageNextLink)
+
+	// Stop after this many items, 0 for no limit.
+	MaxItems int
+
+	// For long-polling feeds, if not 0: on reaching the end of the
+	// collection (an empty page, or no next cursor), wait this long and ask
+	// again from where we got to, instead of stopping.  With no next cursor
+	// or link to go on, that is the last page again, less the items already
+	// returned from it.  Iteration then only ends with MaxItems, an error,
+	// or cancellation of the request Context.
+	PollInterval time.Duration
+}
+
+// Paging of Redfish resource collections, for collections fetched with a
+// plain HTTPRequest (see the redfish package for a full Redfish client).
+var RedfishPagination = Pagination{
+	Style:    PageNextLink,
+	ItemsKey: "Members",
+	NextKey:  "Members@odata.nextLink",
+}
+
+// Iterates over the items of a paged collection API, fetching pages as
+// they are needed.  Used like a bufio.Scanner:
+//
+//  it := base.NewPageIterator(request, base.Pagination{ItemsKey: "Components"})
+//  for it.Next() {
+//      var comp base.Component
+//      if err := it.Decode(&comp); err != nil {
+//          ...
+//      }
+//  }
+//  if err := it.Err(); err != nil {
+//      ...
+//  }
+//
+// or with PageItems() to range over typed items directly.  Each page is
+// read into memory, so keep MaxResponseBytes in mind for large pages.  A
+// PageIterator is not safe for concurrent use.
+type PageIterator struct {
+	request *HTTPRequest
+	paging  Pagination
+
+	nextURL string // Of the next page, "" if there is none
+	offset  int
+	seen    map[string]bool
+	page    []json.RawMessage
+	item    json.RawMessage
+	count   int
+	polling bool // Last page was the end; wait before asking again
+	skip    int  // Items at the start of the page at nextURL already returned
+	done    bool
+	err     error
+}
+
+// Create a PageIterator over the collection whose first page is fetched by
+// 'request'.  'request' is used as a template for every page: it is copied,
+// and only the copies' FullURL is changed.  Nothing is fetched until
+// Next() is first called.
+func NewPageIterator(request *HTTPRequest, paging Pagination) *PageIterator {
+	if paging.OffsetParam == "" {
+		paging.OffsetParam = "offset"
+	}
+	if paging.LimitParam == "" {
+		paging.LimitParam = "limit"
+	}
+	if paging.CursorParam == "" {
+		paging.CursorParam = "cursor"
+	}
+	if paging.Style == PageOffset && paging.PageSize <= 0 {
+		paging.PageSize = DefaultPageSize
+	}
+	it := &PageIterator{request: request, paging: paging, seen: map[string]bool{}}
+	it.nextURL, it.err = it.firstURL()
+	return it
+}
+
+func (it *PageIterator) firstURL() (string, error) {
+	u, err := url.Parse(it.request.FullURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid collection URL: %w", err)
+	}
+	query := u.Query()
+	switch it.paging.Style {
+	case PageOffset:
+		query.Set(it.paging.OffsetParam, "0")
+		query.Set(it.paging.LimitParam, strconv.Itoa(it.paging.PageSize))
+	default:
+		if it.paging.PageSize > 0 {
+			query.Set(it.paging.LimitParam, strconv.Itoa(it.paging.PageSize))
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+func (it *PageIterator) context() context.Context {
+	if it.request.Context == nil {
+		return context.Background()
+	}
+	return it.request.Context
+}
+
+// Move on to the next item, fetching the next page if need be.  Returns
+// false at the end of the collection, on reaching MaxItems, or on error
+// (see Err()).
+func (it *PageIterator) Next() bool {
+	it.item = nil
+	if it.err != nil || it.done {
+		return false
+	}
+	if it.paging.MaxItems > 0 && it.count >= it.paging.MaxItems {
+		it.done = true
+		return false
+	}
+	for len(it.page) == 0 {
+		if err := it.context().Err(); err != nil {
+			it.err = err
+			return false
+		}
+		if it.nextURL == "" {
+			it.done = true
+			return false
+		}
+		if it.polling {
+			select {
+			case <-time.After(it.paging.PollInterval):
+			case <-it.context().Done():
+				it.err = it.context().Err()
+				return false
+			}
+		}
+		if it.err = it.fetch(); it.err != nil {
+			if ctxErr := it.context().Err(); ctxErr != nil {
+				it.err = ctxErr
+			}
+			return false
+		}
+	}
+	it.item, it.page = it.page[0], it.page[1:]
+	it.count++
+	return true
+}
+
+// Returns the current item, as raw JSON.
+func (it *PageIterator) Item() json.RawMessage {
+	return it.item
+}
+
+// Unmarshal the current item into 'v'.
+func (it *PageIterator) Decode(v interface{}) error {
+	if it.item == nil {
+		return fmt.Errorf("no current item")
+	}
+	if err := json.Unmarshal(it.item, v); err != nil {
+		return fmt.Errorf("unable to unmarshal collection item: %w", err)
+	}
+	return nil
+}
+
+// Returns the error that ended iteration, if any.  If the request Context
+// was cancelled, that is its error.
+func (it *PageIterator) Err() error {
+	return it.err
+}
+
+// Returns the number of items returned so far.
+func (it *PageIterator) Count() int {
+	return it.count
+}
+
+// Fetch the page at nextURL, and work out where the one after it is.
+func (it *PageIterator) fetch() error {
+	pageURL := it.nextURL
+	if it.paging.PollInterval == 0 && it.paging.Style != PageOffset {
+		if it.seen[pageURL] {
+			return fmt.Errorf("collection next page loops back to %s", pageURL)
+		}
+		it.seen[pageURL] = true
+	}
+
+	request := *it.request
+	request.FullURL = pageURL
+	resp, err := request.DoHTTPResponse()
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("unable to read collection page: %w", err)
+	}
+
+	var next json.RawMessage
+	if it.paging.ItemsKey == "" {
+		err = json.Unmarshal(body, &it.page)
+	} else {
+		var members map[string]json.RawMessage
+		if err = json.Unmarshal(body, &members); err == nil {
+			if items := members[it.paging.ItemsKey]; items != nil {
+				err = json.Unmarshal(items, &it.page)
+			}
+			if it.paging.NextKey != "" {
+				next = members[it.paging.NextKey]
+			}
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("unable to unmarshal collection page: %w", err)
+	}
+
+	n := len(it.page)
+	it.nextURL, err = it.followingURL(pageURL, resp.Header, next, n)
+	if err != nil {
+		return err
+	}
+	it.page = it.page[min(it.skip, n):]
+	it.polling = false
+	it.skip = 0
+	if it.paging.PollInterval != 0 && (it.nextURL == "" || n == 0) {
+		// End of the feed, for now.  An empty page's URL is where to ask
+		// again from (the offset after the last item, for PageOffset).
+		// Otherwise ask for the last page again, skipping the items on it
+		// that have been returned already.
+		if it.nextURL == "" {
+			it.nextURL = pageURL
+			it.skip = n
+		}
+		it.polling = true
+	}
+	return nil
+}
+
+// Returns the URL of the page after the one at 'pageURL', which had
+// 'n' items, or "" if there are no more.
+func (it *PageIterator) followingURL(pageURL string, header http.Header, next json.RawMessage, n int) (string, error) {
+	current, err := url.Parse(pageURL)
+	if err != nil {
+		return "", err
+	}
+
+	switch it.paging.Style {
+	case PageOffset:
+		// Only an empty page is the end: a short one may just mean the
+		// service caps the limit below PageSize.
+		if n == 0 {
+			return "", nil
+		}
+		query := current.Query()
+		offset, _ := strconv.Atoi(query.Get(it.paging.OffsetParam))
+		query.Set(it.paging.OffsetParam, strconv.Itoa(offset+n))
+		current.RawQuery = query.Encode()
+		return current.String(), nil
+
+	case PageCursor:
+		cursor := jsonScalar(next)
+		if cursor == "" {
+			return "", nil
+		}
+		query := current.Query()
+		query.Set(it.paging.CursorParam, cursor)
+		current.RawQuery = query.Encode()
+		return current.String(), nil
+
+	case PageNextLink:
+		link := jsonScalar(next)
+		if it.paging.NextKey == "" {
+			link = nextLinkHeader(header)
+		}
+		if link == "" {
+			return "", nil
+		}
+		ref, err := url.Parse(link)
+		if err != nil {
+			return "", fmt.Errorf("invalid collection next link '%s': %w", link, err)
+		}
+		return current.ResolveReference(ref).String(), nil
+	}
+	return "", fmt.Errorf("unknown PageStyle %d", it.paging.Style)
+}
+
+// Returns the JSON string or number 'raw' as a string, "" for anything else.
+func jsonScalar(raw json.RawMessage) string {
+	var v interface{}
+	if len(raw) == 0 || json.Unmarshal(raw, &v) != nil {
+		return ""
+	}
+	switch val := v.(type) {
+	case string:
+		return val
+	case float64:
+		return strings.TrimSpace(string(raw))
+	}
+	return ""
+}
+
+// Returns the target of the RFC 8288 rel="next" Link, if any.
+func nextLinkHeader(header http.Header) string {
+	for _, value := range header.Values("Link") {
+		for _, link := range strings.Split(value, ",") {
+			target, params, _ := strings.Cut(link, ";")
+			target = strings.TrimSpace(target)
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, param := range strings.Split(params, ";") {
+				name, val, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if strings.EqualFold(name, "rel") &&
+					strings.EqualFold(strings.Trim(val, `"`), "next") {
+					return target[1 : len(target)-1]
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// Range over the items of 'it', each unmarshaled into a T.  A failure
+// (fetching a page, or unmarshaling an item) is yielded as the last error;
+// stopping the loop early is fine.
+//
+//  for comp, err := range base.PageItems[base.Component](it) {
+//      if err != nil {
+//          ...
+//      }
+//  }
+func PageItems[T any](it *PageIterator) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for it.Next() {
+			var item T
+			if err := it.Decode(&item); err != nil {
+				yield(item, err)
+				return
+			}
+			if !yield(item, nil) {
+				return
+			}
+		}
+		if err := it.Err(); err != nil {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package base
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type pagerItem struct {
+	ID int `json:"id"`
+}
+
+// Serve items 0 to n-1 in pages of at most 'size', in every paging style.
+func newPagerServer(t *testing.T, n, size int) *httptest.Server {
+	items := func(from int) []pagerItem {
+		page := []pagerItem{}
+		for i := from; i < n && i < from+size; i++ {
+			page = append(page, pagerItem{ID: i})
+		}
+		return page
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch r.URL.Path {
+		case "/offset":
+			offset, _ := strconv.Atoi(q.Get("offset"))
+			if limit, _ := strconv.Atoi(q.Get("limit")); limit != size {
+				t.Errorf("Expected limit %d, got %d", size, limit)
+			}
+			json.NewEncoder(w).Encode(items(offset))
+		case "/cursor":
+			from, _ := strconv.Atoi(q.Get("cursor"))
+			body := map[string]interface{}{"items": items(from)}
+			if from+size < n {
+				body["next"] = strconv.Itoa(from + size)
+			}
+			json.NewEncoder(w).Encode(body)
+		case "/redfish/v1/Chassis":
+			from, _ := strconv.Atoi(q.Get("$skip"))
+			body := map[string]interface{}{"Members": items(from)}
+			if from+size < n {
+				body["Members@odata.nextLink"] = fmt.Sprintf("/redfish/v1/Chassis?$skip=%d", from+size)
+			}
+			json.NewEncoder(w).Encode(body)
+		case "/linkheader":
+			from, _ := strconv.Atoi(q.Get("from"))
+			if from+size < n {
+				w.Header().Set("Link", fmt.Sprintf(`</first>; rel="first", <?from=%d>; rel="next"`, from+size))
+			}
+			json.NewEncoder(w).Encode(items(from))
+		case "/loop":
+			w.Header().Set("Link", `</loop>; rel="next"`)
+			json.NewEncoder(w).Encode(items(0))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func collectIDs(t *testing.T, it *PageIterator) []int {
+	ids := []int{}
+	for item, err := range PageItems[pagerItem](it) {
+		if err != nil {
+			t.Fatalf("Iteration failed after %v: %v", ids, err)
+		}
+		ids = append(ids, item.ID)
+	}
+	return ids
+}
+
+func expectIDs(t *testing.T, ids []int, n int) {
+	t.Helper()
+	if len(ids) != n {
+		t.Fatalf("Expected %d items, got %d: %v", n, len(ids), ids)
+	}
+	for i, id := range ids {
+		if id != i {
+			t.Fatalf("Expected item %d to have ID %d, got %v", i, i, ids)
+		}
+	}
+}
+
+func TestPageIteratorStyles(t *testing.T) {
+	srv := newPagerServer(t, 25, 10)
+	defer srv.Close()
+
+	tests := []struct {
+		path   string
+		paging Pagination
+	}{
+		{"/offset", Pagination{PageSize: 10}},
+		{"/cursor", Pagination{Style: PageCursor, ItemsKey: "items", NextKey: "next"}},
+		{"/redfish/v1/Chassis", RedfishPagination},
+		{"/linkheader", Pagination{Style: PageNextLink}},
+	}
+	for _, tt := range tests {
+		it := NewPageIterator(NewHTTPRequest(srv.URL+tt.path), tt.paging)
+		expectIDs(t, collectIDs(t, it), 25)
+	}
+}
+
+func TestPageIteratorMaxItems(t *testing.T) {
+	var pages int32
+	srv := newPagerServer(t, 100, 10)
+	defer srv.Close()
+	counting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&pages, 1)
+		http.Redirect(w, r, srv.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+	}))
+	defer counting.Close()
+
+	it := NewPageIterator(NewHTTPRequest(counting.URL+"/offset"),
+		Pagination{PageSize: 10, MaxItems: 15})
+	expectIDs(t, collectIDs(t, it), 15)
+	if pages != 2 {
+		t.Errorf("Expected 2 pages to be fetched, got %d", pages)
+	}
+	if it.Count() != 15 {
+		t.Errorf("Expected Count() 15, got %d", it.Count())
+	}
+}
+
+func TestPageIteratorCappedLimit(t *testing.T) {
+	// A service that returns fewer items than asked for, though there are
+	// more to come.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		from, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		page := []pagerItem{}
+		for i := from; i < 25 && i < from+4; i++ {
+			page = append(page, pagerItem{ID: i})
+		}
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer srv.Close()
+
+	it := NewPageIterator(NewHTTPRequest(srv.URL), Pagination{PageSize: 10})
+	expectIDs(t, collectIDs(t, it), 25)
+}
+
+func TestPageIteratorErrors(t *testing.T) {
+	srv := newPagerServer(t, 5, 10)
+	defer srv.Close()
+
+	it := NewPageIterator(NewHTTPRequest(srv.URL+"/loop"), Pagination{Style: PageNextLink})
+	for it.Next() {
+	}
+	if it.Err() == nil {
+		t.Errorf("Expected an error for a looping next link, got none")
+	}
+
+	it = NewPageIterator(NewHTTPRequest(srv.URL+"/missing"), Pagination{})
+	if it.Next() || it.Err() == nil {
+		t.Errorf("Expected an error for a 404, got none")
+	}
+
+	// Stopping early and decoding into the wrong type.
+	it = NewPageIterator(NewHTTPRequest(srv.URL+"/offset"), Pagination{PageSize: 10})
+	for _, err := range PageItems[string](it) {
+		if err == nil {
+			t.Errorf("Expected an error decoding an object into a string, got none")
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	request := NewHTTPRequest(srv.URL + "/offset")
+	request.Context = ctx
+	it = NewPageIterator(request, Pagination{PageSize: 10})
+	if it.Next() || it.Err() != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", it.Err())
+	}
+}
+
+func TestPageIteratorPoll(t *testing.T) {
+	// A change feed that gets a new item on every other request, so is
+	// often empty.
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&requests, 1)) / 2
+		from, _ := strconv.Atoi(r.URL.Query().Get("cursor"))
+		page := []pagerItem{}
+		for i := from; i < n; i++ {
+			page = append(page, pagerItem{ID: i})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"events": page, "next": n})
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	request := NewHTTPRequest(srv.URL)
+	request.Context = ctx
+	it := NewPageIterator(request, Pagination{Style: PageCursor, ItemsKey: "events",
+		NextKey: "next", MaxItems: 5, PollInterval: 10 * time.Millisecond})
+	start := time.Now()
+	expectIDs(t, collectIDs(t, it), 5)
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("Expected waits between empty pages, took only %v", elapsed)
+	}
+
+	// The same feed with offset paging, which must carry on from after the
+	// last item returned rather than fetching it again.
+	atomic.StoreInt32(&requests, 0)
+	offsetSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&requests, 1)) / 2
+		from, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		page := []pagerItem{}
+		for i := from; i < n; i++ {
+			page = append(page, pagerItem{ID: i})
+		}
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer offsetSrv.Close()
+	offsetRequest := NewHTTPRequest(offsetSrv.URL)
+	offsetRequest.Context = ctx
+	it = NewPageIterator(offsetRequest, Pagination{PageSize: 10, MaxItems: 5,
+		PollInterval: 10 * time.Millisecond})
+	expectIDs(t, collectIDs(t, it), 5)
+
+	// A feed whose last page has no next link, and is filled up as items
+	// come in: its items already returned must not be returned again.
+	atomic.StoreInt32(&requests, 0)
+	linkSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&requests, 1)) / 2
+		from, _ := strconv.Atoi(r.URL.Query().Get("$skip"))
+		body := map[string]interface{}{"Members": []pagerItem{}}
+		for i := from; i < n && i < from+2; i++ {
+			body["Members"] = append(body["Members"].([]pagerItem), pagerItem{ID: i})
+		}
+		if from+2 < n {
+			body["Members@odata.nextLink"] = fmt.Sprintf("/?$skip=%d", from+2)
+		}
+		json.NewEncoder(w).Encode(body)
+	}))
+	defer linkSrv.Close()
+	linkRequest := NewHTTPRequest(linkSrv.URL)
+	linkRequest.Context = ctx
+	paging := RedfishPagination
+	paging.MaxItems = 6
+	paging.PollInterval = 10 * time.Millisecond
+	expectIDs(t, collectIDs(t, NewPageIterator(linkRequest, paging)), 6)
+
+	// Without MaxItems, it runs until cancelled.
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	request.Context = ctx
+	it = NewPageIterator(request, Pagination{Style: PageCursor, ItemsKey: "events",
+		NextKey: "next", PollInterval: 10 * time.Millisecond})
+	for it.Next() {
+	}
+	if it.Err() != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", it.Err())
+	}
+}
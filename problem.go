@@ -0,0 +1,527 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package base
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+)
+
+// One parameter that failed validation, in ProblemDetails.InvalidParams.
+type InvalidParam struct {
+	Name   string `json:"name"`   // e.g. "xname", or a JSON field
+	Reason string `json:"reason"` // Why it was rejected
+}
+
+// One occurrence of a problem, in ProblemDetails.Errors, for when there
+// are several of the same type.
+type ProblemOccurrence struct {
+	Detail  string `json:"detail"`            // What went wrong this time
+	Pointer string `json:"pointer,omitempty"` // e.g. JSON Pointer "#/age"
+}
+
+// Extension member names this package uses.  Any others may be used too.
+const (
+	ProblemExtRequestID = "request-id" // ID of the request that had the problem
+	ProblemExtCode      = "code"       // Machine-readable error code
+	ProblemExtXname     = "xname"      // Component the problem concerns
+)
+
+// Names of the members of ProblemDetails that are not extensions.
+var problemDetailsMembers = map[string]bool{
+	"type": true, "title": true, "detail": true, "instance": true,
+	"status": true, "invalid-params": true, "errors": true,
+}
+
+// Returns a copy of p (see NewChild()) with extension member 'name' set to
+// 'value', which must marshal to JSON.  p itself is not changed, so this
+// is safe to use on a shared prototype.
+//
+//  p := problemBadXname.NewChild("", "").
+//      WithExtension(base.ProblemExtXname, xname).
+//      WithExtension(base.ProblemExtRequestID, base.GetRequestID(r.Context()))
+func (p *ProblemDetails) WithExtension(name string, value interface{}) *ProblemDetails {
+	newProb := p.NewChild("", "")
+	if newProb.Extensions == nil {
+		newProb.Extensions = map[string]interface{}{}
+	}
+	newProb.Extensions[name] = value
+	return newProb
+}
+
+// Returns a copy of p (see NewChild()) with an InvalidParam added.  p
+// itself is not changed.
+//
+//  p := base.NewProblemDetailsStatus("Invalid component", http.StatusBadRequest).
+//      WithInvalidParam("ID", "not a valid xname").
+//      WithInvalidParam("State", "must be one of Off, On, Ready")
+func (p *ProblemDetails) WithInvalidParam(name, reason string) *ProblemDetails {
+	newProb := p.NewChild("", "")
+	newProb.InvalidParams = append(newProb.InvalidParams, InvalidParam{Name: name, Reason: reason})
+	return newProb
+}
+
+// Returns a copy of p (see NewChild()) with a ProblemOccurrence added to
+// Errors.  p itself is not changed.  'pointer' locates the problem in the
+// request, e.g. a JSON Pointer fragment like "#/profile/color", and may
+// be empty.
+func (p *ProblemDetails) WithOccurrence(detail, pointer string) *ProblemDetails {
+	newProb := p.NewChild("", "")
+	newProb.Errors = append(newProb.Errors, ProblemOccurrence{Detail: detail, Pointer: pointer})
+	return newProb
+}
+
+// Returns the value of extension member 'name', and whether it is set.
+// Values of unmarshaled ProblemDetails are as decoded by encoding/json
+// into an interface{}.
+func (p *ProblemDetails) Extension(name string) (interface{}, bool) {
+	val, ok := p.Extensions[name]
+	return val, ok
+}
+
+// ProblemDetails without its methods, for the standard members.
+type problemDetailsFields ProblemDetails
+
+// Marshal the standard members, then the extension members in name order.
+func (p ProblemDetails) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(problemDetailsFields(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return data, err
+	}
+
+	names := make([]string, 0, len(p.Extensions))
+	for name := range p.Extensions {
+		if !problemDetailsMembers[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	buf.Write(data[:len(data)-1]) // Without the closing '}'
+	for _, name := range names {
+		key, _ := json.Marshal(name)
+		val, err := json.Marshal(p.Extensions[name])
+		if err != nil {
+			return nil, fmt.Errorf("unable to marshal problem extension '%s': %w", name, err)
+		}
+		buf.WriteByte(',')
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Unmarshal the standard members, and any others into Extensions.
+//
+// As RFC 9457 requires, members with values of the wrong type (e.g. a
+// string "status") are ignored rather than being an error, and a missing
+// "type" means "about:blank".  It is only an error if 'data' is not a JSON
+// object.
+func (p *ProblemDetails) UnmarshalJSON(data []byte) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+	*p = ProblemDetails{}
+	for name, raw := range members {
+		switch name {
+		case "type":
+			decodeProblemMember(raw, &p.Type)
+		case "title":
+			decodeProblemMember(raw, &p.Title)
+		case "detail":
+			decodeProblemMember(raw, &p.Detail)
+		case "instance":
+			decodeProblemMember(raw, &p.Instance)
+		case "status":
+			decodeProblemMember(raw, &p.Status)
+		case "invalid-params":
+			decodeProblemMember(raw, &p.InvalidParams)
+		case "errors":
+			decodeProblemMember(raw, &p.Errors)
+		default:
+			var val interface{}
+			if err := json.Unmarshal(raw, &val); err != nil {
+				return err
+			}
+			if p.Extensions == nil {
+				p.Extensions = map[string]interface{}{}
+			}
+			p.Extensions[name] = val
+		}
+	}
+	if p.Type == "" {
+		p.Type = ProblemDetailsHTTPStatusType
+	}
+	return nil
+}
+
+// Set *dst from 'raw' only if it decodes as the right type.
+func decodeProblemMember[T any](raw json.RawMessage, dst *T) {
+	var val T
+	if err := json.Unmarshal(raw, &val); err == nil {
+		*dst = val
+	}
+}
+
+// Returns a copy of p (see NewChild()) with a relative Type and Instance
+// resolved against 'base', which is the base URI of the problem document,
+// i.e. usually the URL of the request it was the response to.  Absolute
+// URIs, and those that can't be parsed, are left as they are.
+//
+//  var p base.ProblemDetails
+//  if err := json.Unmarshal(body, &p); err == nil {
+//      problem := p.Resolve(resp.Request.URL)
+//      ...
+//  }
+func (p *ProblemDetails) Resolve(base *url.URL) *ProblemDetails {
+	newProb := p.NewChild("", "")
+	if base == nil {
+		return newProb
+	}
+	newProb.Type = resolveProblemURI(base, newProb.Type)
+	newProb.Instance = resolveProblemURI(base, newProb.Instance)
+	return newProb
+}
+
+func resolveProblemURI(base *url.URL, uri string) string {
+	if uri == "" {
+		return uri
+	}
+	ref, err := url.Parse(uri)
+	if err != nil || ref.IsAbs() {
+		return uri
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// Combine problems for a single response, which can only report one
+// problem type.  As RFC 9457 recommends, the first (non-nil) problem, which
+// should be the most relevant or urgent, is the one reported.  Others of the same
+// type add their InvalidParams and Errors to it, and if they have neither,
+// their Detail becomes an occurrence in Errors.  Others of different types
+// are dropped.  None of 'problems' are changed.  nil problems are skipped,
+// and nil is returned if there are no others.
+//
+//  var problems []*base.ProblemDetails
+//  for _, comp := range comps {
+//      if base.VerifyNormalizeState(comp.State) == "" {
+//          problems = append(problems, base.ProblemTypeValidation.New(
+//              "Invalid state for "+comp.ID, "").
+//              WithInvalidParam(comp.ID+".State", "not a valid state"))
+//      }
+//  }
+//  if p := base.MergeProblems(problems...); p != nil {
+//      base.SendProblemDetails(w, p, 0)
+//      return
+//  }
+func MergeProblems(problems ...*ProblemDetails) *ProblemDetails {
+	for len(problems) > 0 && problems[0] == nil {
+		problems = problems[1:]
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	merged := problems[0].NewChild("", "")
+	for _, p := range problems[1:] {
+		if p == nil || problemType(p) != problemType(merged) {
+			continue
+		}
+		merged.InvalidParams = append(merged.InvalidParams, p.InvalidParams...)
+		merged.Errors = append(merged.Errors, p.Errors...)
+		if len(p.InvalidParams) == 0 && len(p.Errors) == 0 && p.Detail != "" {
+			merged.Errors = append(merged.Errors, ProblemOccurrence{Detail: p.Detail})
+		}
+	}
+	return merged
+}
+
+// Type of p, with "about:blank" if it is not set.
+func problemType(p *ProblemDetails) string {
+	if p.Type == "" {
+		return ProblemDetailsHTTPStatusType
+	}
+	return p.Type
+}
+
+////////////////////////////////////////////////////////////////////////////
+// Problem types
+////////////////////////////////////////////////////////////////////////////
+
+// A type of problem, with the fields of a registration in the IANA "HTTP
+// Problem Types" registry (RFC 9457 section 4.2), so that it is documented
+// the same way whether or not it is ever actually registered.
+type ProblemType struct {
+	Type      string // Type URI
+	Title     string // Short, human-readable summary of the type
+	Status    int    // Recommended HTTP status code, 0 if not applicable
+	Reference string // Where the type is specified
+}
+
+// Base of the Type URIs of the common HMS problem types below.
+const ProblemTypeBase = "https://github.com/Cray-HPE/hms-base/problems/"
+
+const problemTypeReference = "github.com/Cray-HPE/hms-base/v2"
+
+// The "about:blank" type from the IANA registry, meaning the problem is no
+// more than its HTTP status code.  Use NewProblemDetailsStatus() for these.
+var ProblemTypeBlank = ProblemType{
+	Type:      ProblemDetailsHTTPStatusType,
+	Title:     "See HTTP Status Code",
+	Reference: "RFC 9457, Section 4.2.1",
+}
+
+// Common problem types for HMS services.
+var (
+	ProblemTypeValidation = ProblemType{
+		Type:      ProblemTypeBase + "validation-error",
+		Title:     "Request is not valid",
+		Status:    http.StatusBadRequest,
+		Reference: problemTypeReference,
+	}
+	ProblemTypeNotFound = ProblemType{
+		Type:      ProblemTypeBase + "not-found",
+		Title:     "Resource not found",
+		Status:    http.StatusNotFound,
+		Reference: problemTypeReference,
+	}
+	ProblemTypeConflict = ProblemType{
+		Type:      ProblemTypeBase + "conflict",
+		Title:     "Conflicts with the current state of the resource",
+		Status:    http.StatusConflict,
+		Reference: problemTypeReference,
+	}
+	ProblemTypePreconditionFailed = ProblemType{
+		Type:      ProblemTypeBase + "precondition-failed",
+		Title:     "Resource has changed",
+		Status:    http.StatusPreconditionFailed,
+		Reference: problemTypeReference,
+	}
+	ProblemTypeRateLimited = ProblemType{
+		Type:      ProblemTypeBase + "rate-limited",
+		Title:     "Too many requests",
+		Status:    http.StatusTooManyRequests,
+		Reference: problemTypeReference,
+	}
+	ProblemTypeInternal = ProblemType{
+		Type:      ProblemTypeBase + "internal-error",
+		Title:     "Internal service error",
+		Status:    http.StatusInternalServerError,
+		Reference: problemTypeReference,
+	}
+	ProblemTypeUpstream = ProblemType{
+		Type:      ProblemTypeBase + "upstream-error",
+		Title:     "Request to another service failed",
+		Status:    http.StatusBadGateway,
+		Reference: problemTypeReference,
+	}
+	ProblemTypeUnavailable = ProblemType{
+		Type:      ProblemTypeBase + "service-unavailable",
+		Title:     "Service is temporarily unavailable",
+		Status:    http.StatusServiceUnavailable,
+		Reference: problemTypeReference,
+	}
+)
+
+var problemTypes = map[string]ProblemType{}
+var problemTypesLock sync.RWMutex
+
+func init() {
+	for _, pt := range []ProblemType{
+		ProblemTypeBlank, ProblemTypeValidation, ProblemTypeNotFound,
+		ProblemTypeConflict, ProblemTypePreconditionFailed,
+		ProblemTypeRateLimited, ProblemTypeInternal, ProblemTypeUpstream,
+		ProblemTypeUnavailable,
+	} {
+		problemTypes[pt.Type] = pt
+	}
+}
+
+// New ProblemDetails of type pt, with its Type, Title and Status.  Either
+// of 'detail' and 'instance' may be the empty string.
+func (pt ProblemType) New(detail, instance string) *ProblemDetails {
+	return NewProblemDetails(pt.Type, pt.Title, detail, instance, pt.Status)
+}
+
+// Returns true if p is of type pt.
+func (pt ProblemType) Matches(p *ProblemDetails) bool {
+	return p != nil && problemType(p) == pt.Type
+}
+
+// Register a service's own problem type, so LookupProblemType() and
+// ProblemTypes() know about it.  Registering the same type again is fine,
+// as long as it is identical.  Its Type must be an absolute URI.
+func RegisterProblemType(pt ProblemType) error {
+	ref, err := url.Parse(pt.Type)
+	if err != nil || !ref.IsAbs() {
+		return fmt.Errorf("problem type '%s' is not an absolute URI", pt.Type)
+	}
+	problemTypesLock.Lock()
+	defer problemTypesLock.Unlock()
+	if old, ok := problemTypes[pt.Type]; ok && old != pt {
+		return fmt.Errorf("problem type '%s' is already registered", pt.Type)
+	}
+	problemTypes[pt.Type] = pt
+	return nil
+}
+
+// Look up a registered problem type by its Type URI.
+func LookupProblemType(typeURI string) (ProblemType, bool) {
+	if typeURI == "" {
+		typeURI = ProblemDetailsHTTPStatusType
+	}
+	problemTypesLock.RLock()
+	defer problemTypesLock.RUnlock()
+	pt, ok := problemTypes[typeURI]
+	return pt, ok
+}
+
+// Returns all registered problem types, in Type order.
+func ProblemTypes() []ProblemType {
+	problemTypesLock.RLock()
+	defer problemTypesLock.RUnlock()
+	pts := make([]ProblemType, 0, len(problemTypes))
+	for _, pt := range problemTypes {
+		pts = append(pts, pt)
+	}
+	sort.Slice(pts, func(i, j int) bool { return pts[i].Type < pts[j].Type })
+	return pts
+}
+
+////////////////////////////////////////////////////////////////////////////
+// Receiving problem details
+////////////////////////////////////////////////////////////////////////////
+
+// Largest problem document ParseProblemDetails() will read.
+const DefaultMaxProblemBytes = 64 * 1024
+
+// Returned by ParseProblemDetails() if a response is not a problem document.
+var ErrNotProblemDetails = errors.New("response is not " + ProblemDetailContentType)
+
+// Parse the problem document in 'resp', e.g. an error response from another
+// HMS service, into an HMSError (see ProblemDetails.HMSError()), so it can
+// be handled, or passed on to our own caller, like any other.  Relative
+// type and instance URIs are resolved against the URL of resp.Request, if
+// any, and a problem with no status gets resp.StatusCode.
+//
+// If 'resp' is not application/problem+json, returns ErrNotProblemDetails
+// and leaves resp.Body alone, so it can be read some other way.  Otherwise
+// resp.Body is read, up to DefaultMaxProblemBytes (more is an error
+// wrapping ErrHTTPResponseTooLarge), and closed.
+//
+//  resp, err := http.DefaultClient.Do(req)
+//  ...
+//  if resp.StatusCode >= 400 {
+//      if hmsErr, err := base.ParseProblemDetails(resp); err == nil {
+//          return hmsErr
+//      }
+//      ...
+//  }
+func ParseProblemDetails(resp *http.Response) (*HMSError, error) {
+	if resp == nil {
+		return nil, ErrNotProblemDetails
+	}
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || mediaType != ProblemDetailContentType || resp.Body == nil {
+		return nil, ErrNotProblemDetails
+	}
+	if resp.ContentLength > DefaultMaxProblemBytes {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: problem document Content-Length %d, limit %d bytes",
+			ErrHTTPResponseTooLarge, resp.ContentLength, DefaultMaxProblemBytes)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, DefaultMaxProblemBytes+1))
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read problem document: %w", err)
+	}
+	if len(data) > DefaultMaxProblemBytes {
+		return nil, fmt.Errorf("%w: problem document limit %d bytes",
+			ErrHTTPResponseTooLarge, DefaultMaxProblemBytes)
+	}
+
+	var p ProblemDetails
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal problem document: %w", err)
+	}
+	if p.Status == 0 {
+		p.Status = resp.StatusCode
+	}
+	problem := &p
+	if resp.Request != nil {
+		problem = p.Resolve(resp.Request.URL)
+	}
+	return problem.HMSError(), nil
+}
+
+// Parse the body of e as a problem document, as ParseProblemDetails()
+// does for a response.  As e has no request URL, relative URIs are left
+// as they are.
+//
+//  if _, err := request.DoHTTPAction(); err != nil {
+//      var statusErr *base.HTTPStatusError
+//      if errors.As(err, &statusErr) {
+//          if hmsErr, perr := statusErr.ParseProblemDetails(); perr == nil {
+//              return hmsErr
+//          }
+//      }
+//      return err
+//  }
+func (e *HTTPStatusError) ParseProblemDetails() (*HMSError, error) {
+	return ParseProblemDetails(&http.Response{
+		StatusCode:    e.StatusCode,
+		Header:        e.Header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+	})
+}
+
+// New HMSError for p, with p attached as its Problem.  Its Class is p's
+// Type (so "about:blank" for a problem that is just an HTTP status), which
+// means it can be tested for with IsHMSErrorClass() and a ProblemType's
+// Type, and its Message is p's Detail, or failing that, its Title or the
+// text for its Status.  Sending its Problem on with SendProblemDetails()
+// passes the problem back up a chain of services unchanged.
+func (p *ProblemDetails) HMSError() *HMSError {
+	msg := p.Detail
+	if msg == "" {
+		msg = p.Title
+	}
+	if msg == "" {
+		msg = http.StatusText(p.Status)
+	}
+	e := NewHMSError(problemType(p), msg)
+	e.AddProblem(p)
+	return e
+}
@@ -0,0 +1,414 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package base
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProblemDetailsExtensions(t *testing.T) {
+	proto := NewProblemDetailsStatus("Invalid component", http.StatusBadRequest)
+	p := proto.WithExtension(ProblemExtXname, "x3000c0s1b0n0").
+		WithExtension(ProblemExtCode, 42).
+		WithExtension("type", "ignored").
+		WithInvalidParam("State", "must be one of Off, On, Ready")
+
+	if proto.Extensions != nil || proto.InvalidParams != nil {
+		t.Errorf("Prototype was modified: %+v", proto)
+	}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	expect := `{"type":"about:blank","title":"Bad Request",` +
+		`"detail":"Invalid component","status":400,"invalid-params":[{"name":"State",` +
+		`"reason":"must be one of Off, On, Ready"}],"code":42,` +
+		`"xname":"x3000c0s1b0n0"}`
+	if string(data) != expect {
+		t.Errorf("Expected JSON\n%s\ngot\n%s", expect, data)
+	}
+
+	var got ProblemDetails
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if got.Type != "about:blank" || got.Status != http.StatusBadRequest ||
+		!reflect.DeepEqual(got.InvalidParams, p.InvalidParams) {
+		t.Errorf("Standard members not decoded: %+v", got)
+	}
+	if val, ok := got.Extension(ProblemExtXname); !ok || val != "x3000c0s1b0n0" {
+		t.Errorf("Expected xname extension, got %v (%t)", val, ok)
+	}
+	if val, _ := got.Extension(ProblemExtCode); val != float64(42) {
+		t.Errorf("Expected code extension 42, got %v", val)
+	}
+	if _, ok := got.Extension("type"); ok || len(got.Extensions) != 2 {
+		t.Errorf("Unexpected extensions: %v", got.Extensions)
+	}
+
+	// Children keep, but don't share, extensions and invalid params.
+	child := p.NewChild("Bad State", "")
+	child.Extensions[ProblemExtXname] = "x0"
+	child.InvalidParams[0].Reason = "changed"
+	if p.Extensions[ProblemExtXname] != "x3000c0s1b0n0" ||
+		p.InvalidParams[0].Reason != "must be one of Off, On, Ready" {
+		t.Errorf("Parent modified through child: %+v", p)
+	}
+
+	// Extensions go out with SendProblemDetails() too.
+	w := httptest.NewRecorder()
+	if err := SendProblemDetails(w, p, 0); err != nil {
+		t.Fatalf("SendProblemDetails failed: %v", err)
+	}
+	if w.Body.String() != expect+"\n" {
+		t.Errorf("Expected sent JSON\n%s\ngot\n%s", expect, w.Body.String())
+	}
+
+	// Extension values that can't be marshaled are an error.
+	if _, err := json.Marshal(proto.WithExtension("bad", make(chan int))); err == nil {
+		t.Errorf("Expected error marshaling unsupported extension value")
+	}
+}
+
+// Examples from RFC 9457.
+func TestProblemDetailsRFC9457Examples(t *testing.T) {
+	// Section 3
+	var p ProblemDetails
+	err := json.Unmarshal([]byte(`{
+	 "type": "https://example.com/probs/out-of-credit",
+	 "title": "You do not have enough credit.",
+	 "detail": "Your current balance is 30, but that costs 50.",
+	 "instance": "/account/12345/msgs/abc",
+	 "balance": 30,
+	 "accounts": ["/account/12345",
+	              "/account/67890"]
+	}`), &p)
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	expect := ProblemDetails{
+		Type:     "https://example.com/probs/out-of-credit",
+		Title:    "You do not have enough credit.",
+		Detail:   "Your current balance is 30, but that costs 50.",
+		Instance: "/account/12345/msgs/abc",
+		Extensions: map[string]interface{}{
+			"balance":  float64(30),
+			"accounts": []interface{}{"/account/12345", "/account/67890"},
+		},
+	}
+	if !reflect.DeepEqual(p, expect) {
+		t.Errorf("Expected %+v, got %+v", expect, p)
+	}
+
+	// Section 3, multiple occurrences of the same problem type
+	p = ProblemDetails{}
+	err = json.Unmarshal([]byte(`{
+	 "type": "https://example.net/validation-error",
+	 "title": "Your request is not valid.",
+	 "errors": [
+	             {
+	               "detail": "must be a positive integer",
+	               "pointer": "#/age"
+	             },
+	             {
+	               "detail": "must be 'green', 'red' or 'blue'",
+	               "pointer": "#/profile/color"
+	             }
+	          ]
+	}`), &p)
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	expectErrs := []ProblemOccurrence{
+		{Detail: "must be a positive integer", Pointer: "#/age"},
+		{Detail: "must be 'green', 'red' or 'blue'", Pointer: "#/profile/color"},
+	}
+	if !reflect.DeepEqual(p.Errors, expectErrs) || p.Extensions != nil {
+		t.Errorf("Expected errors %+v and no extensions, got %+v", expectErrs, p)
+	}
+	built := NewProblemDetails("https://example.net/validation-error",
+		"Your request is not valid.", "", "", 0).
+		WithOccurrence("must be a positive integer", "#/age").
+		WithOccurrence("must be 'green', 'red' or 'blue'", "#/profile/color")
+	if !reflect.DeepEqual(*built, p) {
+		t.Errorf("Built %+v doesn't match RFC example %+v", built, p)
+	}
+
+	// Section 3.1.1, a relative type URI is resolved against the base URI
+	// of the document, as is instance.
+	base, _ := url.Parse("https://example.com/account/12345/msgs")
+	p = ProblemDetails{Type: "/probs/out-of-credit", Instance: "msgs/abc"}
+	r := p.Resolve(base)
+	if r.Type != "https://example.com/probs/out-of-credit" ||
+		r.Instance != "https://example.com/account/12345/msgs/abc" {
+		t.Errorf("Relative URIs not resolved: %+v", r)
+	}
+	if p.Type != "/probs/out-of-credit" {
+		t.Errorf("Resolve() modified the original: %+v", p)
+	}
+	r = r.Resolve(base)
+	if r.Type != "https://example.com/probs/out-of-credit" {
+		t.Errorf("Absolute type URI changed: %s", r.Type)
+	}
+	if r = (&ProblemDetails{Type: ProblemDetailsHTTPStatusType}).Resolve(base); r.Type != "about:blank" {
+		t.Errorf("about:blank changed to %s", r.Type)
+	}
+}
+
+// RFC 9457 section 3.1: members of the wrong type are ignored, and a
+// missing type is "about:blank".
+func TestProblemDetailsTolerantDecoding(t *testing.T) {
+	var p ProblemDetails
+	err := json.Unmarshal([]byte(`{"title": 42, "status": "403",
+		"detail": "Still here", "instance": null,
+		"invalid-params": {"name": "x"}, "errors": "nope",
+		"balance": 30}`), &p)
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	expect := ProblemDetails{
+		Type:       "about:blank",
+		Detail:     "Still here",
+		Extensions: map[string]interface{}{"balance": float64(30)},
+	}
+	if !reflect.DeepEqual(p, expect) {
+		t.Errorf("Expected %+v, got %+v", expect, p)
+	}
+
+	if err := json.Unmarshal([]byte(`{"status": 403.5}`), &p); err != nil || p.Status != 0 {
+		t.Errorf("Expected non-integer status ignored, got %d (%v)", p.Status, err)
+	}
+	for _, bad := range []string{`[]`, `"problem"`, `{"type":`} {
+		if err := json.Unmarshal([]byte(bad), &p); err == nil {
+			t.Errorf("Expected error for '%s'", bad)
+		}
+	}
+}
+
+func TestMergeProblems(t *testing.T) {
+	if MergeProblems() != nil || MergeProblems(nil, nil) != nil {
+		t.Errorf("Expected nil for no problems")
+	}
+	first := ProblemTypeValidation.New("Invalid components", "").
+		WithInvalidParam("x0.State", "not a valid state")
+	second := ProblemTypeValidation.New("", "").
+		WithOccurrence("must be a valid xname", "#/Components/1/ID")
+	third := ProblemTypeValidation.New("Flag is missing", "")
+	other := ProblemTypeConflict.New("Already exists", "")
+
+	p := MergeProblems(nil, first, other, nil, second, third)
+	if p.Type != ProblemTypeValidation.Type || p.Detail != "Invalid components" ||
+		p.Status != http.StatusBadRequest {
+		t.Errorf("Expected first problem to be reported, got %+v", p)
+	}
+	expectParams := []InvalidParam{{Name: "x0.State", Reason: "not a valid state"}}
+	expectErrs := []ProblemOccurrence{
+		{Detail: "must be a valid xname", Pointer: "#/Components/1/ID"},
+		{Detail: "Flag is missing"},
+	}
+	if !reflect.DeepEqual(p.InvalidParams, expectParams) ||
+		!reflect.DeepEqual(p.Errors, expectErrs) {
+		t.Errorf("Expected %+v and %+v, got %+v", expectParams, expectErrs, p)
+	}
+	if len(first.InvalidParams) != 1 || len(first.Errors) != 0 {
+		t.Errorf("First problem was modified: %+v", first)
+	}
+}
+
+func TestProblemTypes(t *testing.T) {
+	p := ProblemTypeNotFound.New("No such component", "/State/Components/x0")
+	if p.Type != ProblemTypeBase+"not-found" || p.Title != ProblemTypeNotFound.Title ||
+		p.Status != http.StatusNotFound || p.Instance != "/State/Components/x0" {
+		t.Errorf("Unexpected ProblemDetails: %+v", p)
+	}
+	if !ProblemTypeNotFound.Matches(p) || ProblemTypeConflict.Matches(p) ||
+		!ProblemTypeBlank.Matches(&ProblemDetails{}) || ProblemTypeBlank.Matches(nil) {
+		t.Errorf("Matches() gave wrong results")
+	}
+
+	if pt, ok := LookupProblemType(""); !ok || pt != ProblemTypeBlank {
+		t.Errorf("Expected about:blank registered, got %+v (%t)", pt, ok)
+	}
+	if pt, ok := LookupProblemType(ProblemTypeUpstream.Type); !ok || pt != ProblemTypeUpstream {
+		t.Errorf("Expected upstream-error registered, got %+v (%t)", pt, ok)
+	}
+
+	custom := ProblemType{
+		Type:      "https://example.com/probs/out-of-credit",
+		Title:     "You do not have enough credit.",
+		Status:    http.StatusForbidden,
+		Reference: "RFC 9457",
+	}
+	if err := RegisterProblemType(custom); err != nil {
+		t.Fatalf("RegisterProblemType failed: %v", err)
+	}
+	if err := RegisterProblemType(custom); err != nil {
+		t.Errorf("Re-registering identical type failed: %v", err)
+	}
+	changed := custom
+	changed.Status = http.StatusPaymentRequired
+	if err := RegisterProblemType(changed); err == nil {
+		t.Errorf("Expected error re-registering a different type")
+	}
+	if err := RegisterProblemType(ProblemType{Type: "/probs/relative"}); err == nil {
+		t.Errorf("Expected error registering a relative type URI")
+	}
+	if pt, ok := LookupProblemType(custom.Type); !ok || pt != custom {
+		t.Errorf("Expected custom type registered, got %+v (%t)", pt, ok)
+	}
+
+	pts := ProblemTypes()
+	for i := 1; i < len(pts); i++ {
+		if pts[i-1].Type >= pts[i].Type {
+			t.Errorf("ProblemTypes() not sorted: %s, %s", pts[i-1].Type, pts[i].Type)
+		}
+	}
+	if len(pts) != 10 {
+		t.Errorf("Expected 10 problem types, got %d", len(pts))
+	}
+}
+
+func TestParseProblemDetails(t *testing.T) {
+	var send func(w http.ResponseWriter)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		send(w)
+	}))
+	defer srv.Close()
+	get := func() *http.Response {
+		resp, err := http.Get(srv.URL + "/hsm/v2/State/Components/x0")
+		if err != nil {
+			t.Fatalf("GET failed: %v", err)
+		}
+		return resp
+	}
+
+	// As sent by another HMS service.
+	sent := ProblemTypeNotFound.New("No such component", "x0").
+		WithExtension(ProblemExtXname, "x0")
+	send = func(w http.ResponseWriter) { SendProblemDetails(w, sent, 0) }
+	hmsErr, err := ParseProblemDetails(get())
+	if err != nil {
+		t.Fatalf("ParseProblemDetails failed: %v", err)
+	}
+	if hmsErr.Class != ProblemTypeNotFound.Type || hmsErr.Message != "No such component" ||
+		!IsHMSErrorClass(hmsErr, ProblemTypeNotFound.Type) {
+		t.Errorf("Unexpected HMSError: %+v", hmsErr)
+	}
+	p := hmsErr.GetProblem()
+	if p == nil || p.Status != http.StatusNotFound || p.Extensions[ProblemExtXname] != "x0" {
+		t.Fatalf("Unexpected Problem: %+v", p)
+	}
+	if p.Instance != srv.URL+"/hsm/v2/State/Components/x0" {
+		t.Errorf("Expected instance resolved against request URL, got %s", p.Instance)
+	}
+
+	// Passed back up the chain unchanged (apart from the resolved instance).
+	w := httptest.NewRecorder()
+	SendProblemDetails(w, hmsErr.GetProblem(), 0)
+	hmsErr2, err := ParseProblemDetails(w.Result())
+	if err != nil || !reflect.DeepEqual(hmsErr2.GetProblem(), p) {
+		t.Errorf("Problem changed passing it on: %+v, %v", hmsErr2, err)
+	}
+
+	// Status from the response, message from the title or status.
+	send = func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "Application/Problem+JSON; charset=utf-8")
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"title": "Already locked"}`))
+	}
+	hmsErr, err = ParseProblemDetails(get())
+	if err != nil || hmsErr.Class != "about:blank" || hmsErr.Message != "Already locked" ||
+		hmsErr.Problem.Status != http.StatusConflict {
+		t.Errorf("Unexpected result: %+v, %v", hmsErr, err)
+	}
+	if msg := (&ProblemDetails{Status: http.StatusConflict}).HMSError().Message; msg != "Conflict" {
+		t.Errorf("Expected message 'Conflict', got '%s'", msg)
+	}
+
+	// Not a problem document, body left for the caller.
+	send = func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": "bad"}`))
+	}
+	resp := get()
+	if _, err := ParseProblemDetails(resp); !errors.Is(err, ErrNotProblemDetails) {
+		t.Errorf("Expected ErrNotProblemDetails, got %v", err)
+	}
+	if body, _ := io.ReadAll(resp.Body); string(body) != `{"error": "bad"}` {
+		t.Errorf("Body was consumed: '%s'", body)
+	}
+	resp.Body.Close()
+	if _, err := ParseProblemDetails(nil); !errors.Is(err, ErrNotProblemDetails) {
+		t.Errorf("Expected ErrNotProblemDetails for nil response, got %v", err)
+	}
+
+	// Too large, with and without a Content-Length.
+	big := `{"detail": "` + strings.Repeat("x", DefaultMaxProblemBytes) + `"}`
+	for _, chunked := range []bool{false, true} {
+		send = func(w http.ResponseWriter) {
+			w.Header().Set("Content-Type", ProblemDetailContentType)
+			if chunked {
+				w.Write([]byte(big[:10]))
+				w.(http.Flusher).Flush()
+				w.Write([]byte(big[10:]))
+			} else {
+				w.Write([]byte(big))
+			}
+		}
+		if _, err := ParseProblemDetails(get()); !errors.Is(err, ErrHTTPResponseTooLarge) {
+			t.Errorf("Chunked %t: expected ErrHTTPResponseTooLarge, got %v", chunked, err)
+		}
+	}
+
+	// Not a JSON object.
+	send = func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", ProblemDetailContentType)
+		w.Write([]byte(`["problem"]`))
+	}
+	if _, err := ParseProblemDetails(get()); err == nil || errors.Is(err, ErrNotProblemDetails) {
+		t.Errorf("Expected unmarshal error, got %v", err)
+	}
+
+	// From an HTTPStatusError.
+	send = func(w http.ResponseWriter) { SendProblemDetails(w, sent, 0) }
+	_, err = NewHTTPRequest(srv.URL).DoHTTPAction()
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("Expected HTTPStatusError, got %v", err)
+	}
+	hmsErr, err = statusErr.ParseProblemDetails()
+	if err != nil || !ProblemTypeNotFound.Matches(hmsErr.GetProblem()) ||
+		hmsErr.GetProblem().Instance != "x0" {
+		t.Errorf("Unexpected result from HTTPStatusError: %+v, %v", hmsErr, err)
+	}
+}
@@ -0,0 +1,344 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+// Package redfish is a client for the DMTF Redfish API spoken by BMCs, built
+// on hms-base's HTTPRequest so it shares its HTTPClient, TLS settings,
+// retries, request IDs and tracing.
+//
+//  c := redfish.NewClient("x3000c0s1b0", "root", password)
+//  defer c.Logout(ctx)
+//
+//  var system struct {
+//      redfish.Resource
+//      PowerState string
+//  }
+//  err := c.Get(ctx, "/redfish/v1/Systems/Node0", &system)
+//
+//  err = c.ForEachMember(ctx, "/redfish/v1/Chassis",
+//      func(member json.RawMessage) error {
+//          ...
+//      }, redfish.Expand(1), redfish.Select("Id", "PowerState"))
+//
+// Sessions are used for authentication by default, with the client logging
+// in on first use and again if the session expires.
+package redfish
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+)
+
+const ServiceRootURI = "/redfish/v1"
+const SessionsURI = "/redfish/v1/SessionService/Sessions"
+
+const AuthTokenHeader = "X-Auth-Token"
+
+// Default limit on the size of responses read into memory.
+const DefaultMaxResponseBytes = 16 * 1024 * 1024
+
+// How the Client authenticates to the BMC.
+type AuthMode int
+
+const (
+	AuthSession AuthMode = 0 // Log in to a session and use its X-Auth-Token
+	AuthBasic   AuthMode = 1 // HTTP basic authentication on every request
+)
+
+// A Client talks to one Redfish service, e.g. a BMC.  Its exported fields
+// may be changed after NewClient() but not once it is in use.  It is safe
+// for concurrent use.
+type Client struct {
+	Endpoint         string              // Scheme and host, e.g. "https://x3000c0s1b0"
+	Username         string              // Account to log in with
+	Password         string              // Password for Username
+	AuthMode         AuthMode            // Sessions (default) or basic auth
+	HTTPClient       *base.HTTPClient    // nil to use the shared one
+	SkipTLSVerify    bool                // BMCs very often have self-signed certs
+	Timeout          time.Duration       // Per-request timeout
+	MaxResponseBytes int64               // Largest response read into memory
+	Cache            *base.ResponseCache // For GET responses, nil for none
+
+	mu         sync.Mutex
+	token      string
+	sessionURI string
+}
+
+// Create a new Client for the Redfish service at 'endpoint', which is a
+// hostname (e.g. an xname) or a URL with a scheme and host.  A bare
+// hostname means https.
+func NewClient(endpoint, username, password string) *Client {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	return &Client{
+		Endpoint:         strings.TrimRight(endpoint, "/"),
+		Username:         username,
+		Password:         password,
+		Timeout:          30 * time.Second,
+		MaxResponseBytes: DefaultMaxResponseBytes,
+	}
+}
+
+// A response from the Redfish service.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Unmarshal the response body into 'v'.  An empty body leaves 'v' alone.
+func (r *Response) Decode(v interface{}) error {
+	if len(r.Body) == 0 || v == nil {
+		return nil
+	}
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		return fmt.Errorf("unable to unmarshal Redfish response: %w", err)
+	}
+	return nil
+}
+
+// Full URL for 'uri', which can be a path (with or without query), as in an
+// @odata.id, or already a full URL.
+func (c *Client) URL(uri string) string {
+	if strings.HasPrefix(uri, "http://") || strings.HasPrefix(uri, "https://") {
+		return uri
+	}
+	if !strings.HasPrefix(uri, "/") {
+		uri = "/" + uri
+	}
+	return c.Endpoint + uri
+}
+
+// Make a request to the Redfish service.  'body', if not nil, is marshaled
+// to JSON (or sent as-is if it is a []byte or json.RawMessage).  Any 2xx
+// status is a success; anything else is returned as an *Error.
+//
+// With AuthSession, the client logs in first if it has no session, and if
+// the session turns out to have expired (401), logs in again and retries
+// once.
+func (c *Client) Do(ctx context.Context, method, uri string, body interface{}, opts ...QueryOption) (*Response, error) {
+	var payload []byte
+	switch b := body.(type) {
+	case nil:
+	case []byte:
+		payload = b
+	case json.RawMessage:
+		payload = b
+	default:
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return nil, fmt.Errorf("unable to marshal Redfish request: %w", err)
+		}
+	}
+	fullURL := addQuery(c.URL(uri), opts)
+
+	if c.AuthMode == AuthBasic {
+		return c.do(ctx, method, fullURL, payload, "")
+	}
+
+	token, err := c.sessionToken(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(ctx, method, fullURL, payload, token)
+	if IsStatus(err, http.StatusUnauthorized) {
+		// Session probably expired or was deleted behind our back.
+		if token, err = c.sessionToken(ctx, token); err != nil {
+			return nil, err
+		}
+		resp, err = c.do(ctx, method, fullURL, payload, token)
+	}
+	return resp, err
+}
+
+func (c *Client) do(ctx context.Context, method, fullURL string, payload []byte, token string) (*Response, error) {
+	resp, err := c.newRequest(ctx, method, fullURL, payload, token).DoHTTPResponse()
+	if err != nil {
+		return nil, statusError(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read Redfish response: %w", err)
+	}
+	return &Response{StatusCode: resp.StatusCode, Header: resp.Header, Body: body}, nil
+}
+
+func (c *Client) newRequest(ctx context.Context, method, fullURL string, payload []byte, token string) *base.HTTPRequest {
+	request := base.NewHTTPRequest(fullURL)
+	if ctx != nil {
+		request.Context = ctx
+	}
+	request.Method = method
+	request.Payload = payload
+	request.Client = c.HTTPClient
+	request.SkipTLSVerify = c.SkipTLSVerify
+	request.Timeout = c.Timeout
+	request.MaxResponseBytes = c.MaxResponseBytes
+	if ctx == nil || ctx.Value(noCacheKey{}) == nil {
+		request.Cache = c.Cache
+	}
+	request.ExpectedStatusCode = 0
+	request.Header = http.Header{
+		"Accept":        {"application/json"},
+		"OData-Version": {"4.0"},
+	}
+	if token != "" {
+		request.Header.Set(AuthTokenHeader, token)
+	} else if c.AuthMode == AuthBasic {
+		request.Auth = &base.Auth{Username: c.Username, Password: c.Password}
+	}
+	return request
+}
+
+// Context key marking requests that must not be answered from c.Cache.
+type noCacheKey struct{}
+
+// Returns 'ctx' marked so requests made with it bypass the Client's Cache,
+// e.g. to poll something that changes, like a task monitor.
+func withoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheKey{}, true)
+}
+
+// Turn an HTTPStatusError into an *Error.  Other errors are returned as-is.
+func statusError(err error) error {
+	var statusErr *base.HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return NewError(statusErr.StatusCode, statusErr.Body)
+	}
+	return err
+}
+
+// GET 'uri' and unmarshal the result into 'v'.
+func (c *Client) Get(ctx context.Context, uri string, v interface{}, opts ...QueryOption) error {
+	resp, err := c.Do(ctx, http.MethodGet, uri, nil, opts...)
+	if err != nil {
+		return err
+	}
+	return resp.Decode(v)
+}
+
+// POST 'body' to 'uri' and unmarshal the result, if any, into 'v' (which may
+// be nil).  Use Do() directly if the response headers are needed, e.g. the
+// Location of a created resource or task.
+func (c *Client) Post(ctx context.Context, uri string, body, v interface{}) error {
+	resp, err := c.Do(ctx, http.MethodPost, uri, body)
+	if err != nil {
+		return err
+	}
+	return resp.Decode(v)
+}
+
+// PATCH 'uri' with 'body' and unmarshal the result, if any, into 'v' (which
+// may be nil).
+func (c *Client) Patch(ctx context.Context, uri string, body, v interface{}) error {
+	resp, err := c.Do(ctx, http.MethodPatch, uri, body)
+	if err != nil {
+		return err
+	}
+	return resp.Decode(v)
+}
+
+// DELETE 'uri'.
+func (c *Client) Delete(ctx context.Context, uri string) error {
+	_, err := c.Do(ctx, http.MethodDelete, uri, nil)
+	return err
+}
+
+////////////////////////////////////////////////////////////////////////////
+// Sessions
+////////////////////////////////////////////////////////////////////////////
+
+// Returns the token of the current session, logging in if there is none or
+// if the current one is 'stale' (i.e. has just been rejected).
+func (c *Client) sessionToken(ctx context.Context, stale string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && c.token != stale {
+		return c.token, nil
+	}
+	if err := c.login(ctx); err != nil {
+		return "", err
+	}
+	return c.token, nil
+}
+
+// Log in to a new session, replacing the current one if any.  This is done
+// automatically when needed, so there is usually no need to call it.
+func (c *Client) Login(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.login(ctx)
+}
+
+func (c *Client) login(ctx context.Context) error {
+	creds := map[string]string{"UserName": c.Username, "Password": c.Password}
+	payload, _ := json.Marshal(creds)
+	resp, err := c.do(ctx, http.MethodPost, c.URL(SessionsURI), payload, "")
+	if err != nil {
+		return fmt.Errorf("unable to log in to Redfish session: %w", err)
+	}
+	token := resp.Header.Get(AuthTokenHeader)
+	if token == "" {
+		return fmt.Errorf("unable to log in to Redfish session: no %s in response", AuthTokenHeader)
+	}
+	sessionURI := resp.Header.Get("Location")
+	if sessionURI == "" {
+		var session Resource
+		resp.Decode(&session)
+		sessionURI = session.ODataID
+	}
+	c.token = token
+	c.sessionURI = sessionURI
+	return nil
+}
+
+// Delete the current session, if any.  The client can still be used
+// afterwards and will log in again when it is.
+func (c *Client) Logout(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token == "" {
+		return nil
+	}
+	token, sessionURI := c.token, c.sessionURI
+	c.token, c.sessionURI = "", ""
+	if sessionURI == "" {
+		return nil
+	}
+	_, err := c.do(ctx, http.MethodDelete, c.URL(sessionURI), nil, token)
+	if err != nil && !IsStatus(err, http.StatusUnauthorized) &&
+		!IsStatus(err, http.StatusNotFound) {
+		return fmt.Errorf("unable to log out of Redfish session: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,333 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package redfish
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// Test Helper Functions
+///////////////////////////////////////////////////////////////////////////////
+
+// Just enough of a BMC for the client tests.
+type testBMC struct {
+	mu       sync.Mutex
+	tokens   map[string]bool
+	logins   int
+	logouts  int
+	queries  []string
+	basicOK  bool
+	nextID   int
+	srv      *httptest.Server
+	pageSize int
+}
+
+func newTestBMC(t *testing.T) *testBMC {
+	bmc := &testBMC{tokens: map[string]bool{}, pageSize: 2}
+	bmc.srv = httptest.NewServer(http.HandlerFunc(bmc.serveHTTP))
+	t.Cleanup(bmc.srv.Close)
+	return bmc
+}
+
+func (bmc *testBMC) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	bmc.mu.Lock()
+	defer bmc.mu.Unlock()
+
+	if r.URL.Path == SessionsURI && r.Method == "POST" {
+		var creds map[string]string
+		json.NewDecoder(r.Body).Decode(&creds)
+		if creds["UserName"] != "root" || creds["Password"] != "secret" {
+			writeTestError(w, http.StatusUnauthorized, "Base.1.8.NoValidSession", "Bad credentials")
+			return
+		}
+		bmc.logins++
+		bmc.nextID++
+		token := fmt.Sprintf("token%d", bmc.nextID)
+		bmc.tokens[token] = true
+		w.Header().Set(AuthTokenHeader, token)
+		w.Header().Set("Location", fmt.Sprintf("%s/%d", SessionsURI, bmc.nextID))
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+
+	user, pass, basic := r.BasicAuth()
+	if !bmc.tokens[r.Header.Get(AuthTokenHeader)] &&
+		!(bmc.basicOK && basic && user == "root" && pass == "secret") {
+		writeTestError(w, http.StatusUnauthorized, "Base.1.8.NoValidSession", "No session")
+		return
+	}
+	bmc.queries = append(bmc.queries, r.URL.RawQuery)
+
+	switch {
+	case strings.HasPrefix(r.URL.Path, SessionsURI+"/") && r.Method == "DELETE":
+		bmc.logouts++
+		delete(bmc.tokens, r.Header.Get(AuthTokenHeader))
+		w.WriteHeader(http.StatusNoContent)
+	case r.URL.Path == "/redfish/v1/Systems":
+		// 5 members, in pages of bmc.pageSize
+		skip := 0
+		fmt.Sscanf(r.URL.Query().Get("$skip"), "%d", &skip)
+		coll := map[string]interface{}{
+			"@odata.id":           "/redfish/v1/Systems",
+			"Members@odata.count": 5,
+		}
+		members := []interface{}{}
+		for i := skip; i < 5 && i < skip+bmc.pageSize; i++ {
+			members = append(members, map[string]string{
+				"@odata.id": fmt.Sprintf("/redfish/v1/Systems/Node%d", i),
+			})
+		}
+		coll["Members"] = members
+		if skip+bmc.pageSize < 5 {
+			coll["Members@odata.nextLink"] = fmt.Sprintf("/redfish/v1/Systems?$skip=%d", skip+bmc.pageSize)
+		}
+		json.NewEncoder(w).Encode(coll)
+	case strings.HasPrefix(r.URL.Path, "/redfish/v1/Systems/Node"):
+		if r.Method == "PATCH" {
+			writeTestError(w, http.StatusBadRequest, "Base.1.8.GeneralError", "",
+				MessageInfo{
+					MessageID:         "Base.1.8.PropertyValueNotInList",
+					Message:           "The value Sideways for the property PowerState is not in the list of acceptable values.",
+					RelatedProperties: []string{"#/PowerState"},
+				})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"@odata.id":  r.URL.Path,
+			"Id":         strings.TrimPrefix(r.URL.Path, "/redfish/v1/Systems/"),
+			"PowerState": "On",
+		})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func writeTestError(w http.ResponseWriter, status int, code, msg string, info ...MessageInfo) {
+	var body errorBody
+	body.Error.Code = code
+	body.Error.Message = msg
+	body.Error.ExtendedInfo = info
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// Unit Tests
+///////////////////////////////////////////////////////////////////////////////
+
+func TestClientSessions(t *testing.T) {
+	bmc := newTestBMC(t)
+	ctx := context.Background()
+	c := NewClient(bmc.srv.URL, "root", "secret")
+
+	var system struct {
+		Resource
+		PowerState string
+	}
+	if err := c.Get(ctx, "/redfish/v1/Systems/Node0", &system); err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	if system.ID != "Node0" || system.PowerState != "On" {
+		t.Errorf("Unexpected system: %+v", system)
+	}
+	// Session is reused.
+	if err := c.Get(ctx, "/redfish/v1/Systems/Node1", &system); err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	if bmc.logins != 1 {
+		t.Errorf("Expected 1 login, got %d", bmc.logins)
+	}
+
+	// Session expires on the BMC, client logs in again.
+	bmc.mu.Lock()
+	bmc.tokens = map[string]bool{}
+	bmc.mu.Unlock()
+	if err := c.Get(ctx, "/redfish/v1/Systems/Node1", &system); err != nil {
+		t.Fatalf("Get() after session expiry failed: %v", err)
+	}
+	if bmc.logins != 2 {
+		t.Errorf("Expected 2 logins, got %d", bmc.logins)
+	}
+
+	if err := c.Logout(ctx); err != nil {
+		t.Errorf("Logout() failed: %v", err)
+	}
+	if bmc.logouts != 1 || len(bmc.tokens) != 0 {
+		t.Errorf("Session not deleted, logouts: %d, tokens: %v", bmc.logouts, bmc.tokens)
+	}
+	if err := c.Logout(ctx); err != nil {
+		t.Errorf("Second Logout() failed: %v", err)
+	}
+
+	// Bad credentials
+	c = NewClient(bmc.srv.URL, "root", "wrong")
+	err := c.Get(ctx, "/redfish/v1/Systems/Node0", &system)
+	if !IsStatus(err, http.StatusUnauthorized) {
+		t.Errorf("Expected 401 error, got %v", err)
+	}
+}
+
+func TestClientBasicAuth(t *testing.T) {
+	bmc := newTestBMC(t)
+	bmc.basicOK = true
+	c := NewClient(bmc.srv.URL, "root", "secret")
+	c.AuthMode = AuthBasic
+
+	var system Resource
+	if err := c.Get(context.Background(), "/redfish/v1/Systems/Node3", &system); err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	if system.ID != "Node3" || bmc.logins != 0 {
+		t.Errorf("Unexpected system %+v or logins %d", system, bmc.logins)
+	}
+}
+
+func TestClientCollections(t *testing.T) {
+	bmc := newTestBMC(t)
+	ctx := context.Background()
+	c := NewClient(bmc.srv.URL, "root", "secret")
+
+	links, err := c.GetMembers(ctx, "/redfish/v1/Systems")
+	if err != nil {
+		t.Fatalf("GetMembers() failed: %v", err)
+	}
+	if len(links) != 5 || links[4].ODataID != "/redfish/v1/Systems/Node4" {
+		t.Errorf("Unexpected members: %v", links)
+	}
+
+	var system Resource
+	if err := c.Follow(ctx, links[2], &system); err != nil || system.ID != "Node2" {
+		t.Errorf("Follow() got %+v, %v", system, err)
+	}
+	if err := c.Follow(ctx, Link{}, &system); err == nil {
+		t.Errorf("Expected error following empty link")
+	}
+
+	// Query options go on the first page only.
+	bmc.queries = nil
+	count := 0
+	stop := errors.New("stop")
+	err = c.ForEachMember(ctx, "/redfish/v1/Systems", func(member json.RawMessage) error {
+		count++
+		if count == 3 {
+			return stop
+		}
+		return nil
+	}, Expand(1), Select("Id", "PowerState"))
+	if err != stop || count != 3 {
+		t.Errorf("Expected stop after 3 members, got %v after %d", err, count)
+	}
+	if len(bmc.queries) != 2 ||
+		bmc.queries[0] != "$expand=.($levels=1)&$select=Id,PowerState" ||
+		bmc.queries[1] != "$skip=2" {
+		t.Errorf("Unexpected queries: %v", bmc.queries)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	bmc := newTestBMC(t)
+	ctx := context.Background()
+	c := NewClient(bmc.srv.URL, "root", "secret")
+
+	err := c.Patch(ctx, "/redfish/v1/Systems/Node0", map[string]string{"PowerState": "Sideways"}, nil)
+	var rfErr *Error
+	if !errors.As(err, &rfErr) {
+		t.Fatalf("Expected *Error, got %T: %v", err, err)
+	}
+	if rfErr.StatusCode != http.StatusBadRequest || rfErr.Code != "Base.1.8.GeneralError" ||
+		!rfErr.HasMessage("Base.PropertyValueNotInList") {
+		t.Errorf("Unexpected error: %+v", rfErr)
+	}
+	if !rfErr.IsClass("Base.PropertyValueNotInList") {
+		t.Errorf("Unexpected class: %s", rfErr.Class)
+	}
+	if !strings.Contains(err.Error(), "Sideways") {
+		t.Errorf("Message missing detail: %s", err)
+	}
+	var hmsErr *base.HMSError
+	if !errors.As(err, &hmsErr) || hmsErr.GetProblem() == nil ||
+		hmsErr.GetProblem().Status != http.StatusBadRequest {
+		t.Errorf("Expected HMSError with ProblemDetails, got %+v", hmsErr)
+	}
+
+	err = c.Get(ctx, "/redfish/v1/Nothing", nil)
+	if !IsStatus(err, http.StatusNotFound) {
+		t.Errorf("Expected 404 error, got %v", err)
+	}
+	if !errors.As(err, &rfErr) || rfErr.Class != ErrClassRedfish {
+		t.Errorf("Expected generic Redfish class, got %v", rfErr)
+	}
+}
+
+func TestNewError(t *testing.T) {
+	tests := []struct {
+		body  string
+		class string
+		msg   string
+	}{
+		{``, ErrClassRedfish, "Not Found"},
+		{`<html>Not here</html>`, ErrClassRedfish, "Not Found"},
+		{`{"error": {"code": "Base.1.0.ResourceMissingAtURI", "message": "Gone"}}`,
+			"Base.ResourceMissingAtURI", "Gone"},
+		{`{"error": {"code": "Base.1.0.GeneralError", "message": "See ExtendedInfo",
+			"@Message.ExtendedInfo": [{"MessageId": "iDRAC.2.8.SYS403", "Message": "Bad URI"}]}}`,
+			"iDRAC.SYS403", "Bad URI"},
+	}
+	for i, test := range tests {
+		e := NewError(http.StatusNotFound, []byte(test.body))
+		if e.Class != test.class || !strings.HasSuffix(e.Message, test.msg) {
+			t.Errorf("Test %d: expected class '%s' message '%s', got '%s' '%s'",
+				i, test.class, test.msg, e.Class, e.Message)
+		}
+	}
+}
+
+func TestURL(t *testing.T) {
+	c := NewClient("x3000c0s1b0", "", "")
+	tests := map[string]string{
+		"/redfish/v1":             "https://x3000c0s1b0/redfish/v1",
+		"redfish/v1":              "https://x3000c0s1b0/redfish/v1",
+		"http://other/redfish/v1": "http://other/redfish/v1",
+	}
+	for uri, expect := range tests {
+		if got := c.URL(uri); got != expect {
+			t.Errorf("URL(%s): expected '%s', got '%s'", uri, expect, got)
+		}
+	}
+	if got := addQuery("https://h/x?a=b", []QueryOption{Filter("Id eq 'x y'")}); got !=
+		"https://h/x?a=b&$filter=Id%20eq%20'x%20y'" {
+		t.Errorf("Unexpected query: %s", got)
+	}
+}
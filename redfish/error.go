@@ -0,0 +1,145 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package redfish
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+)
+
+// HMSError Class for Redfish errors with no message ID to go by.
+const ErrClassRedfish = "Redfish"
+
+// One entry of a Redfish @Message.ExtendedInfo array.
+type MessageInfo struct {
+	MessageID         string        `json:"MessageId"`
+	Message           string        `json:"Message,omitempty"`
+	MessageArgs       []interface{} `json:"MessageArgs,omitempty"`
+	Severity          string        `json:"Severity,omitempty"` // Deprecated by Redfish, but still common
+	MessageSeverity   string        `json:"MessageSeverity,omitempty"`
+	Resolution        string        `json:"Resolution,omitempty"`
+	RelatedProperties []string      `json:"RelatedProperties,omitempty"`
+}
+
+// The message ID without its registry version, e.g. "Base.GeneralError"
+// for "Base.1.8.GeneralError", so it can be compared across services that
+// implement different versions of a registry.
+func (m *MessageInfo) MessageKey() string {
+	return messageKey(m.MessageID)
+}
+
+func messageKey(id string) string {
+	parts := strings.Split(id, ".")
+	if len(parts) < 3 {
+		return id
+	}
+	return parts[0] + "." + parts[len(parts)-1]
+}
+
+// Error returned for a Redfish request that failed with an HTTP error
+// status.  It is an HMSError (embedded), with
+//
+//   - Class: the version-less message ID (see MessageInfo.MessageKey()) of
+//     the first @Message.ExtendedInfo entry, or else of the error code, or
+//     else ErrClassRedfish.
+//   - Message: the most specific message available.
+//   - Problem: a ProblemDetails for the HTTP status with the same Detail,
+//     ready to pass on to our own callers.
+//
+// The full Redfish error is kept in Code and ExtendedInfo.
+type Error struct {
+	*base.HMSError
+	StatusCode   int
+	Code         string
+	ExtendedInfo []MessageInfo
+}
+
+// The body of a Redfish error response.
+type errorBody struct {
+	Error struct {
+		Code         string        `json:"code"`
+		Message      string        `json:"message"`
+		ExtendedInfo []MessageInfo `json:"@Message.ExtendedInfo"`
+	} `json:"error"`
+}
+
+// Create an Error from a failed response's status and body.  Bodies that
+// aren't Redfish errors (many BMCs return HTML or nothing at all for some
+// errors) just produce a generic message for the status.
+func NewError(status int, body []byte) *Error {
+	e := &Error{StatusCode: status}
+	var eb errorBody
+	if json.Unmarshal(body, &eb) == nil {
+		e.Code = eb.Error.Code
+		e.ExtendedInfo = eb.Error.ExtendedInfo
+	}
+
+	class, msg := ErrClassRedfish, eb.Error.Message
+	if e.Code != "" {
+		class = messageKey(e.Code)
+	}
+	if len(e.ExtendedInfo) > 0 {
+		if e.ExtendedInfo[0].MessageID != "" {
+			class = e.ExtendedInfo[0].MessageKey()
+		}
+		if e.ExtendedInfo[0].Message != "" {
+			msg = e.ExtendedInfo[0].Message
+		}
+	}
+	if msg == "" {
+		msg = http.StatusText(status)
+	}
+	msg = fmt.Sprintf("Redfish request failed with status %d: %s", status, msg)
+
+	e.HMSError = base.NewHMSError(class, msg)
+	e.AddProblem(base.NewProblemDetailsStatus(msg, status))
+	return e
+}
+
+// Unwrap returns the embedded HMSError.
+func (e *Error) Unwrap() error {
+	return e.HMSError
+}
+
+// Returns true if any ExtendedInfo entry has message key 'key' (see
+// MessageInfo.MessageKey()), e.g. "Base.PropertyValueNotInList".
+func (e *Error) HasMessage(key string) bool {
+	for i := range e.ExtendedInfo {
+		if e.ExtendedInfo[i].MessageKey() == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if 'err' is (or wraps) a Redfish *Error with HTTP status
+// 'status'.
+func IsStatus(err error, status int) bool {
+	var rfErr *Error
+	return errors.As(err, &rfErr) && rfErr.StatusCode == status
+}
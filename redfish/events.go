@@ -0,0 +1,509 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package redfish
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+)
+
+const EventServiceURI = "/redfish/v1/EventService"
+const SubscriptionsURI = "/redfish/v1/EventService/Subscriptions"
+
+// Default number of recent EventIds remembered for de-duplication.
+const DefaultEventDedupSize = 1024
+
+// Default limit on the size of an event POST.
+const DefaultMaxEventBytes = 1024 * 1024
+
+// A Redfish Event, as POSTed to subscribers or sent on an SSE stream.
+type Event struct {
+	ODataType string        `json:"@odata.type,omitempty"`
+	ID        string        `json:"Id,omitempty"`
+	Name      string        `json:"Name,omitempty"`
+	Context   string        `json:"Context,omitempty"`
+	Events    []EventRecord `json:"Events"`
+}
+
+// One record of an Event.  Source is not part of the Redfish payload; it is
+// set by the EventListener to the Endpoint of the subscription's Client
+// (or, for events from unknown senders, their IP address).  For events
+// from a subscription, Context is its Options.Context.
+type EventRecord struct {
+	EventType         string        `json:"EventType,omitempty"` // Deprecated by Redfish, but still common
+	EventID           string        `json:"EventId,omitempty"`
+	EventTimestamp    string        `json:"EventTimestamp,omitempty"`
+	Severity          string        `json:"Severity,omitempty"`
+	MessageSeverity   string        `json:"MessageSeverity,omitempty"`
+	Message           string        `json:"Message,omitempty"`
+	MessageID         string        `json:"MessageId"`
+	MessageArgs       []interface{} `json:"MessageArgs,omitempty"`
+	OriginOfCondition *Link         `json:"OriginOfCondition,omitempty"`
+	Context           string        `json:"Context,omitempty"`
+
+	Source string `json:"-"`
+}
+
+// The message ID without its registry version, as for MessageInfo.
+func (r *EventRecord) MessageKey() string {
+	return messageKey(r.MessageID)
+}
+
+// Receives event records, see EventListener.Handle().
+type EventHandler func(rec *EventRecord)
+
+// Options for EventListener.Subscribe().  Destination is required; the
+// rest narrow down which events the service sends.
+type SubscriptionOptions struct {
+	Destination      string   // URL the service POSTs events to
+	Context          string   // Echoed back in each event (see Subscription)
+	RegistryPrefixes []string // e.g. "ResourceEvent"
+	ResourceTypes    []string // e.g. "ComputerSystem"
+	EventTypes       []string // For services that predate RegistryPrefixes
+}
+
+// An event subscription made by an EventListener.  It is not changed once
+// made: if Renew() has to subscribe again, the EventListener replaces it
+// with a new one (see Subscriptions()).
+//
+// The Context given to the service is Options.Context with a random suffix,
+// unique to the subscription, so that events are credited to the service
+// that sent them even when subscriptions share an Options.Context.
+type Subscription struct {
+	Client  *Client
+	Options SubscriptionOptions
+	URI     string // Of the EventDestination resource on the service
+	Context string // Given to the service, "" if Options.Context is
+}
+
+// EventListener receives Redfish events and hands them to handlers.  It
+// is an http.Handler that accepts events POSTed to it (mount it wherever
+// the subscriptions' Destination points), and can also read SSE streams
+// (see ListenSSE()).
+//
+// Events are checked to be well formed and, if any subscription was made
+// with a Context, to carry the Context of one of them, which identifies
+// the sender.  Records already seen (by
+// EventId, per source) are dropped, since services resend events they
+// don't think were delivered.
+//
+// Handlers run in the goroutine that received the event, or, if Pool is
+// set, as Jobs on the WorkerPool.  Set the exported fields before use.
+//
+//  l := redfish.NewEventListener()
+//  l.Handle("ResourceEvent", func(rec *redfish.EventRecord) { ... })
+//  http.Handle("/events", l)
+//  _, err := l.Subscribe(ctx, client, redfish.SubscriptionOptions{
+//      Destination: "https://myservice/events",
+//      Context:     "myservice",
+//  })
+//  go l.RenewSubscriptions(ctx, 10*time.Minute)
+//  ...
+//  l.Close(ctx)
+type EventListener struct {
+	Pool         *base.WorkerPool // If set, handlers run as jobs on this pool
+	DedupSize    int              // Number of EventIds remembered
+	MaxBodyBytes int64            // Largest event POST accepted
+	Logger       *slog.Logger     // nil for slog.Default()
+
+	mu       sync.Mutex
+	handlers []eventHandlerEntry
+	subs     []*Subscription
+	seen     map[string]bool
+	seenRing []string
+	seenNext int
+}
+
+type eventHandlerEntry struct {
+	key     string
+	handler EventHandler
+}
+
+// Create a new EventListener with no handlers or subscriptions.
+func NewEventListener() *EventListener {
+	return &EventListener{
+		DedupSize:    DefaultEventDedupSize,
+		MaxBodyBytes: DefaultMaxEventBytes,
+		seen:         map[string]bool{},
+	}
+}
+
+// Register 'h' for event records whose message key (see
+// MessageInfo.MessageKey()) or registry prefix is 'key', e.g.
+// "ResourceEvent.ResourcePowerStateChanged" or "ResourceEvent".  An empty
+// key matches all records.  A record is passed to every matching handler.
+func (l *EventListener) Handle(key string, h EventHandler) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.handlers = append(l.handlers, eventHandlerEntry{key: key, handler: h})
+}
+
+func (l *EventListener) logger() *slog.Logger {
+	if l.Logger != nil {
+		return l.Logger
+	}
+	return slog.Default()
+}
+
+////////////////////////////////////////////////////////////////////////////
+// Receiving events
+////////////////////////////////////////////////////////////////////////////
+
+func (l *EventListener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		base.SendProblemDetailsGeneric(w, http.StatusMethodNotAllowed, "Events must be POSTed")
+		return
+	}
+	maxBytes := l.MaxBodyBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxEventBytes
+	}
+	var ev Event
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBytes)).Decode(&ev); err != nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest, "Malformed event: "+err.Error())
+		return
+	}
+	if ev.Events == nil {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest, "Malformed event: no Events")
+		return
+	}
+
+	sub, ok := l.subscriptionFor(ev.Context)
+	if !ok {
+		base.SendProblemDetailsGeneric(w, http.StatusBadRequest, "Unknown event Context")
+		return
+	}
+	var source string
+	if sub != nil {
+		// Handlers see the Context they subscribed with.
+		source = sub.Client.Endpoint
+		for i := range ev.Events {
+			if ev.Events[i].Context == ev.Context {
+				ev.Events[i].Context = sub.Options.Context
+			}
+		}
+		ev.Context = sub.Options.Context
+	} else {
+		source, _, _ = net.SplitHostPort(r.RemoteAddr)
+	}
+	w.WriteHeader(http.StatusNoContent)
+	l.dispatch(&ev, source)
+}
+
+// Returns the subscription whose service sends Context 'context'.  If no
+// subscription has a Context any is accepted, from an unknown subscription.
+func (l *EventListener) subscriptionFor(context string) (*Subscription, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	checked := false
+	for _, sub := range l.subs {
+		if sub.Context == "" {
+			continue
+		}
+		if sub.Context == context {
+			return sub, true
+		}
+		checked = true
+	}
+	return nil, !checked
+}
+
+// Hand the (not already seen) records of 'ev' to their handlers.
+func (l *EventListener) dispatch(ev *Event, source string) {
+	for i := range ev.Events {
+		rec := ev.Events[i]
+		rec.Source = source
+		if rec.Context == "" {
+			rec.Context = ev.Context
+		}
+		if rec.EventID != "" && l.isDuplicate(source+"\x00"+rec.EventID) {
+			continue
+		}
+		for _, h := range l.handlersFor(&rec) {
+			l.run(h, &rec)
+		}
+	}
+}
+
+// Returns true if 'key' has been seen recently, and remembers it if not.
+func (l *EventListener) isDuplicate(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.seen[key] {
+		return true
+	}
+	size := l.DedupSize
+	if size <= 0 {
+		size = DefaultEventDedupSize
+	}
+	if len(l.seenRing) < size {
+		l.seenRing = append(l.seenRing, key)
+	} else {
+		l.seenNext %= len(l.seenRing)
+		delete(l.seen, l.seenRing[l.seenNext])
+		l.seenRing[l.seenNext] = key
+		l.seenNext++
+	}
+	l.seen[key] = true
+	return false
+}
+
+func (l *EventListener) handlersFor(rec *EventRecord) []EventHandler {
+	key := rec.MessageKey()
+	prefix := key
+	if i := strings.Index(key, "."); i >= 0 {
+		prefix = key[:i]
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var hs []EventHandler
+	for _, e := range l.handlers {
+		if e.key == "" || e.key == key || e.key == prefix {
+			hs = append(hs, e.handler)
+		}
+	}
+	return hs
+}
+
+func (l *EventListener) run(h EventHandler, rec *EventRecord) {
+	if l.Pool == nil {
+		h(rec)
+		return
+	}
+	job := &eventJob{handler: h, rec: rec, logger: l.logger()}
+	if l.Pool.Queue(job) != 0 {
+		// Queue full; better late than never.
+		l.logger().Warn("Event job queue full, handling event inline",
+			"event_id", rec.EventID, "source", rec.Source)
+		h(rec)
+	}
+}
+
+// JobType of the Jobs that run event handlers on a WorkerPool.
+const JTYPE_REDFISH_EVENT base.JobType = 1000
+
+// Runs an EventHandler as a base.Job.
+type eventJob struct {
+	mu      sync.Mutex
+	status  base.JobStatus
+	err     error
+	handler EventHandler
+	rec     *EventRecord
+	logger  *slog.Logger
+}
+
+func (j *eventJob) Log(format string, a ...interface{}) {
+	j.logger.Info(fmt.Sprintf(format, a...))
+}
+
+func (j *eventJob) Type() base.JobType {
+	return JTYPE_REDFISH_EVENT
+}
+
+func (j *eventJob) Run() {
+	j.handler(j.rec)
+}
+
+func (j *eventJob) GetStatus() (base.JobStatus, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.status, j.err
+}
+
+func (j *eventJob) SetStatus(status base.JobStatus, err error) (base.JobStatus, error) {
+	if status >= base.JSTAT_MAX {
+		return j.GetStatus()
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	old := j.status
+	j.status, j.err = status, err
+	return old, nil
+}
+
+func (j *eventJob) Cancel() base.JobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.status == base.JSTAT_QUEUED || j.status == base.JSTAT_DEFAULT {
+		j.status = base.JSTAT_CANCELLED
+	}
+	return j.status
+}
+
+////////////////////////////////////////////////////////////////////////////
+// Subscriptions
+////////////////////////////////////////////////////////////////////////////
+
+// Subscribe to events from the service 'c' talks to, to be sent to
+// opts.Destination.  The subscription is renewed by RenewSubscriptions()
+// and deleted by Close().
+func (l *EventListener) Subscribe(ctx context.Context, c *Client, opts SubscriptionOptions) (*Subscription, error) {
+	sub := &Subscription{Client: c, Options: opts}
+	if opts.Context != "" {
+		suffix := make([]byte, 6)
+		rand.Read(suffix)
+		sub.Context = opts.Context + "." + hex.EncodeToString(suffix)
+	}
+	if err := sub.create(ctx); err != nil {
+		return nil, err
+	}
+	l.mu.Lock()
+	l.subs = append(l.subs, sub)
+	l.mu.Unlock()
+	return sub, nil
+}
+
+func (sub *Subscription) create(ctx context.Context) error {
+	body := map[string]interface{}{
+		"Destination": sub.Options.Destination,
+		"Protocol":    "Redfish",
+	}
+	if sub.Context != "" {
+		body["Context"] = sub.Context
+	}
+	if len(sub.Options.RegistryPrefixes) > 0 {
+		body["RegistryPrefixes"] = sub.Options.RegistryPrefixes
+	}
+	if len(sub.Options.ResourceTypes) > 0 {
+		body["ResourceTypes"] = sub.Options.ResourceTypes
+	}
+	if len(sub.Options.EventTypes) > 0 {
+		body["EventTypes"] = sub.Options.EventTypes
+	}
+
+	resp, err := sub.Client.Do(ctx, http.MethodPost, SubscriptionsURI, body)
+	if err != nil {
+		return fmt.Errorf("unable to subscribe to Redfish events: %w", err)
+	}
+	uri := resp.Header.Get("Location")
+	if uri == "" {
+		var res Resource
+		resp.Decode(&res)
+		uri = res.ODataID
+	}
+	if uri == "" {
+		return fmt.Errorf("unable to subscribe to Redfish events: no subscription URI in response")
+	}
+	sub.URI = uri
+	return nil
+}
+
+// Check that each subscription still exists on its service, and subscribe
+// again if it doesn't (e.g. because the BMC was reset to defaults).
+// Returns the first error, after trying all subscriptions.
+func (l *EventListener) Renew(ctx context.Context) error {
+	l.mu.Lock()
+	subs := append([]*Subscription{}, l.subs...)
+	l.mu.Unlock()
+
+	var firstErr error
+	for _, sub := range subs {
+		err := sub.Client.Get(ctx, sub.URI, nil)
+		if IsStatus(err, http.StatusNotFound) {
+			l.logger().Info("Redfish event subscription lost, subscribing again",
+				"endpoint", sub.Client.Endpoint, "uri", sub.URI)
+			err = l.replace(ctx, sub)
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Subscribe again in place of 'sub'.  If 'sub' has gone meanwhile (e.g.
+// Close() was called, or another Renew() replaced it), the new
+// subscription is deleted again.
+func (l *EventListener) replace(ctx context.Context, sub *Subscription) error {
+	newSub := &Subscription{Client: sub.Client, Options: sub.Options, Context: sub.Context}
+	if err := newSub.create(ctx); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	for i, s := range l.subs {
+		if s == sub {
+			l.subs[i] = newSub
+			l.mu.Unlock()
+			return nil
+		}
+	}
+	l.mu.Unlock()
+	err := newSub.Client.Delete(ctx, newSub.URI)
+	if err != nil && !IsStatus(err, http.StatusNotFound) {
+		return fmt.Errorf("unable to delete Redfish event subscription %s: %w", newSub.URI, err)
+	}
+	return nil
+}
+
+// Returns the current subscriptions.
+func (l *EventListener) Subscriptions() []*Subscription {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]*Subscription{}, l.subs...)
+}
+
+// Call Renew() every 'interval' until 'ctx' is done.  Errors are logged.
+func (l *EventListener) RenewSubscriptions(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := l.Renew(ctx); err != nil {
+				l.logger().Warn("Unable to renew Redfish event subscriptions", "error", err)
+			}
+		}
+	}
+}
+
+// Delete all subscriptions.  Returns the first error, after trying all
+// of them.
+func (l *EventListener) Close(ctx context.Context) error {
+	l.mu.Lock()
+	subs := l.subs
+	l.subs = nil
+	l.mu.Unlock()
+
+	var firstErr error
+	for _, sub := range subs {
+		err := sub.Client.Delete(ctx, sub.URI)
+		if err != nil && !IsStatus(err, http.StatusNotFound) && firstErr == nil {
+			firstErr = fmt.Errorf("unable to delete Redfish event subscription %s: %w", sub.URI, err)
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,404 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package redfish
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+)
+
+func postEvent(t *testing.T, l *EventListener, ev interface{}) int {
+	body, _ := json.Marshal(ev)
+	req := httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader(body))
+	req.RemoteAddr = "10.0.0.1:4321"
+	rec := httptest.NewRecorder()
+	l.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func testEvent(context string, ids ...string) *Event {
+	ev := &Event{ODataType: "#Event.v1_7_0.Event", Context: context, Events: []EventRecord{}}
+	for _, id := range ids {
+		msg := "ResourceEvent.1.0.ResourceCreated"
+		if strings.HasPrefix(id, "alert") {
+			msg = "Alert.1.0.LanDisconnect"
+		}
+		ev.Events = append(ev.Events, EventRecord{EventID: id, MessageID: msg})
+	}
+	return ev
+}
+
+// Collects records passed to handlers.
+type recordSink struct {
+	mu   sync.Mutex
+	recs []EventRecord
+}
+
+func (s *recordSink) handle(rec *EventRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recs = append(s.recs, *rec)
+}
+
+func (s *recordSink) ids() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []string
+	for _, rec := range s.recs {
+		ids = append(ids, rec.EventID)
+	}
+	return ids
+}
+
+func TestEventListenerDispatch(t *testing.T) {
+	l := NewEventListener()
+	var all, resource, lan recordSink
+	l.Handle("", all.handle)
+	l.Handle("ResourceEvent", resource.handle)
+	l.Handle("Alert.LanDisconnect", lan.handle)
+
+	if code := postEvent(t, l, testEvent("", "1", "alert2")); code != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d", code)
+	}
+	// Resent by the service.
+	postEvent(t, l, testEvent("", "1", "3"))
+
+	if ids := all.ids(); fmt.Sprint(ids) != "[1 alert2 3]" {
+		t.Errorf("Unexpected records for all: %v", ids)
+	}
+	if ids := resource.ids(); fmt.Sprint(ids) != "[1 3]" {
+		t.Errorf("Unexpected records for ResourceEvent: %v", ids)
+	}
+	if ids := lan.ids(); fmt.Sprint(ids) != "[alert2]" {
+		t.Errorf("Unexpected records for Alert.LanDisconnect: %v", ids)
+	}
+	if all.recs[0].Source != "10.0.0.1" {
+		t.Errorf("Unexpected source: %s", all.recs[0].Source)
+	}
+
+	if code := postEvent(t, l, map[string]string{"Id": "1"}); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for event without Events, got %d", code)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/events", strings.NewReader("{"))
+	rec := httptest.NewRecorder()
+	l.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for malformed JSON, got %d", rec.Code)
+	}
+	req = httptest.NewRequest(http.MethodGet, "/events", nil)
+	rec = httptest.NewRecorder()
+	l.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for GET, got %d", rec.Code)
+	}
+}
+
+func TestEventListenerDedupSize(t *testing.T) {
+	l := NewEventListener()
+	l.DedupSize = 2
+	var all recordSink
+	l.Handle("", all.handle)
+
+	postEvent(t, l, testEvent("", "1", "2", "3"))
+	// 1 has been forgotten, 3 hasn't.
+	postEvent(t, l, testEvent("", "1", "3"))
+	if ids := all.ids(); fmt.Sprint(ids) != "[1 2 3 1]" {
+		t.Errorf("Unexpected records: %v", ids)
+	}
+}
+
+func TestEventListenerPool(t *testing.T) {
+	pool := base.NewWorkerPool(2, 10)
+	pool.Run()
+	defer pool.Stop()
+
+	l := NewEventListener()
+	l.Pool = pool
+	done := make(chan string, 3)
+	l.Handle("", func(rec *EventRecord) { done <- rec.EventID })
+
+	postEvent(t, l, testEvent("", "1", "2", "3"))
+	got := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		select {
+		case id := <-done:
+			got[id] = true
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for event jobs, got %v", got)
+		}
+	}
+	if len(got) != 3 {
+		t.Errorf("Unexpected records: %v", got)
+	}
+}
+
+// Just enough of an EventService for the subscription tests.
+type testEventService struct {
+	mu      sync.Mutex
+	subs    map[string]map[string]interface{}
+	nextID  int
+	sseConn int
+}
+
+func newTestEventService(t *testing.T) (*testEventService, *Client) {
+	es := &testEventService{subs: map[string]map[string]interface{}{}}
+	srv := httptest.NewServer(http.HandlerFunc(es.serveHTTP))
+	t.Cleanup(srv.Close)
+	c := NewClient(srv.URL, "root", "secret")
+	c.AuthMode = AuthBasic
+	return es, c
+}
+
+func (es *testEventService) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	es.mu.Lock()
+	switch {
+	case r.URL.Path == EventServiceURI:
+		es.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]string{
+			"@odata.id":          EventServiceURI,
+			"ServerSentEventUri": "/redfish/v1/EventService/SSE",
+		})
+	case r.URL.Path == SubscriptionsURI && r.Method == http.MethodPost:
+		defer es.mu.Unlock()
+		var sub map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&sub)
+		es.nextID++
+		uri := fmt.Sprintf("%s/%d", SubscriptionsURI, es.nextID)
+		es.subs[uri] = sub
+		w.Header().Set("Location", uri)
+		w.WriteHeader(http.StatusCreated)
+	case strings.HasPrefix(r.URL.Path, SubscriptionsURI+"/"):
+		defer es.mu.Unlock()
+		if _, ok := es.subs[r.URL.Path]; !ok {
+			writeTestError(w, http.StatusNotFound, "Base.1.8.ResourceMissingAtURI", "")
+			return
+		}
+		if r.Method == http.MethodDelete {
+			delete(es.subs, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		json.NewEncoder(w).Encode(es.subs[r.URL.Path])
+	case r.URL.Path == "/redfish/v1/EventService/SSE":
+		es.sseConn++
+		conn := es.sseConn
+		es.mu.Unlock()
+		es.serveSSE(w, r, conn)
+	default:
+		es.mu.Unlock()
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// The first connection sends two events and ends; the second resends the
+// last one (as if it hadn't seen it delivered), sends a third, and stays
+// open.
+func (es *testEventService) serveSSE(w http.ResponseWriter, r *http.Request, conn int) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	send := func(id string) {
+		data, _ := json.Marshal(testEvent("", id))
+		fmt.Fprintf(w, ": keepalive\nid: %s\ndata: %s\n\n", id, data)
+		w.(http.Flusher).Flush()
+	}
+	if conn == 1 {
+		send("1")
+		send("2")
+		return
+	}
+	if r.Header.Get("Last-Event-ID") != "2" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	send("2")
+	send("3")
+	<-r.Context().Done()
+}
+
+func TestEventListenerSubscriptions(t *testing.T) {
+	es, c := newTestEventService(t)
+	l := NewEventListener()
+	ctx := context.Background()
+	var all recordSink
+	l.Handle("", all.handle)
+
+	opts := SubscriptionOptions{
+		Destination:      "https://listener/events",
+		Context:          "mine",
+		RegistryPrefixes: []string{"ResourceEvent"},
+	}
+	sub, err := l.Subscribe(ctx, c, opts)
+	if err != nil {
+		t.Fatalf("Unable to subscribe: %v", err)
+	}
+	es.mu.Lock()
+	created := es.subs[sub.URI]
+	es.mu.Unlock()
+	if created["Destination"] != opts.Destination || created["Context"] != sub.Context ||
+		!strings.HasPrefix(sub.Context, "mine.") || created["Protocol"] != "Redfish" {
+		t.Errorf("Unexpected subscription: %v", created)
+	}
+
+	// Events must now carry the subscription's Context, and handlers see
+	// the one they asked for.
+	if code := postEvent(t, l, testEvent("mine", "1")); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for unknown Context, got %d", code)
+	}
+	postEvent(t, l, testEvent(sub.Context, "2"))
+	if len(all.recs) != 1 || all.recs[0].Source != c.Endpoint || all.recs[0].Context != "mine" {
+		t.Errorf("Unexpected records: %+v", all.recs)
+	}
+
+	// Lost subscriptions are re-created.
+	es.mu.Lock()
+	delete(es.subs, sub.URI)
+	es.mu.Unlock()
+	if err := l.Renew(ctx); err != nil {
+		t.Fatalf("Unable to renew: %v", err)
+	}
+	es.mu.Lock()
+	count := len(es.subs)
+	es.mu.Unlock()
+	subs := l.Subscriptions()
+	if count != 1 || len(subs) != 1 || subs[0].URI != SubscriptionsURI+"/2" ||
+		subs[0].Context != sub.Context || sub.URI != SubscriptionsURI+"/1" {
+		t.Errorf("Subscription not replaced: %d, %+v", count, subs)
+	}
+
+	// Renewals racing each other and Close() (run with -race).
+	es.mu.Lock()
+	delete(es.subs, subs[0].URI)
+	es.mu.Unlock()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.Renew(ctx)
+		}()
+	}
+	wg.Wait()
+	es.mu.Lock()
+	count = len(es.subs)
+	es.mu.Unlock()
+	if subs = l.Subscriptions(); count != 1 || len(subs) != 1 {
+		t.Errorf("Expected 1 subscription after concurrent renewals, got %d, %+v", count, subs)
+	}
+
+	if err := l.Close(ctx); err != nil {
+		t.Fatalf("Unable to close: %v", err)
+	}
+	if len(es.subs) != 0 {
+		t.Errorf("Subscriptions left after Close(): %v", es.subs)
+	}
+}
+
+// Subscriptions to several BMCs can share a Context, and each BMC's events
+// are still told apart, even with the same EventIds.
+func TestEventListenerSharedContext(t *testing.T) {
+	l := NewEventListener()
+	var all recordSink
+	l.Handle("", all.handle)
+	opts := SubscriptionOptions{Destination: "https://listener/events", Context: "mine"}
+	var subs []*Subscription
+	for i := 0; i < 2; i++ {
+		_, c := newTestEventService(t)
+		sub, err := l.Subscribe(context.Background(), c, opts)
+		if err != nil {
+			t.Fatalf("Unable to subscribe: %v", err)
+		}
+		subs = append(subs, sub)
+	}
+	if subs[0].Context == subs[1].Context {
+		t.Fatalf("Subscriptions given the same Context %s", subs[0].Context)
+	}
+
+	postEvent(t, l, testEvent(subs[0].Context, "1"))
+	postEvent(t, l, testEvent(subs[1].Context, "1"))
+	if len(all.recs) != 2 || all.recs[0].Source != subs[0].Client.Endpoint ||
+		all.recs[1].Source != subs[1].Client.Endpoint ||
+		all.recs[0].Context != "mine" || all.recs[1].Context != "mine" {
+		t.Errorf("Unexpected records: %+v", all.recs)
+	}
+}
+
+func TestEventListenerSSE(t *testing.T) {
+	// A Client with a Cache must still deliver events as they come, not
+	// wait for the stream to be read into the cache.
+	for _, cache := range []*base.ResponseCache{nil, base.NewResponseCache(10, time.Hour)} {
+		_, c := newTestEventService(t)
+		c.Cache = cache
+		l := NewEventListener()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var all recordSink
+		l.Handle("", func(rec *EventRecord) {
+			all.handle(rec)
+			if len(all.ids()) == 3 {
+				cancel()
+			}
+		})
+		err := l.ListenSSE(ctx, c, "RegistryPrefix eq 'ResourceEvent'")
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled (cache %t), got %v", cache != nil, err)
+		}
+		if ids := all.ids(); fmt.Sprint(ids) != "[1 2 3]" {
+			t.Errorf("Unexpected SSE records (cache %t): %v", cache != nil, ids)
+		}
+	}
+}
+
+func TestReadSSE(t *testing.T) {
+	stream := ": comment\n" +
+		"event: message\n" +
+		"id: 7\n" +
+		"data: line one\n" +
+		"data:line two\n" +
+		"\n" +
+		"data: no id change\n" +
+		"\n" +
+		"\n"
+	var got []string
+	err := readSSE(strings.NewReader(stream), func(id, data string) error {
+		got = append(got, id+"="+data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("readSSE failed: %v", err)
+	}
+	expected := []string{"7=line one\nline two", "7=no id change"}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
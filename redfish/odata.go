@@ -0,0 +1,196 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package redfish
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Properties common to all Redfish resources.
+type Resource struct {
+	ODataID   string `json:"@odata.id"`
+	ODataType string `json:"@odata.type,omitempty"`
+	ID        string `json:"Id,omitempty"`
+	Name      string `json:"Name,omitempty"`
+}
+
+// A reference to another resource, e.g. a collection member or an entry in
+// Links.
+type Link struct {
+	ODataID string `json:"@odata.id"`
+}
+
+// One page of a Redfish resource collection.  Members are links unless the
+// collection was requested with Expand(), in which case they are the full
+// resources.
+type Collection struct {
+	Resource
+	Members      []json.RawMessage `json:"Members"`
+	MembersCount int               `json:"Members@odata.count"`
+	NextLink     string            `json:"Members@odata.nextLink,omitempty"`
+}
+
+// GET the resource 'link' refers to and unmarshal it into 'v'.
+func (c *Client) Follow(ctx context.Context, link Link, v interface{}, opts ...QueryOption) error {
+	if link.ODataID == "" {
+		return fmt.Errorf("unable to follow empty Redfish link")
+	}
+	return c.Get(ctx, link.ODataID, v, opts...)
+}
+
+// Call 'fn' for each member of the collection at 'uri', following
+// Members@odata.nextLink through every page.  If 'fn' returns an error,
+// traversal stops and that error is returned.  'opts' apply to the first
+// page; the service includes whatever is needed in its next links.
+func (c *Client) ForEachMember(ctx context.Context, uri string, fn func(member json.RawMessage) error, opts ...QueryOption) error {
+	seen := map[string]bool{}
+	for uri != "" {
+		if seen[uri] {
+			return fmt.Errorf("Redfish collection next link loops back to %s", uri)
+		}
+		seen[uri] = true
+
+		var page Collection
+		resp, err := c.Do(ctx, http.MethodGet, uri, nil, opts...)
+		if err != nil {
+			return err
+		}
+		if err := resp.Decode(&page); err != nil {
+			return err
+		}
+		for _, member := range page.Members {
+			if err := fn(member); err != nil {
+				return err
+			}
+		}
+		uri, opts = page.NextLink, nil
+	}
+	return nil
+}
+
+// Returns links to all members of the collection at 'uri', across all
+// pages.
+func (c *Client) GetMembers(ctx context.Context, uri string) ([]Link, error) {
+	links := []Link{}
+	err := c.ForEachMember(ctx, uri, func(member json.RawMessage) error {
+		var link Link
+		if err := json.Unmarshal(member, &link); err != nil {
+			return fmt.Errorf("unable to unmarshal Redfish collection member: %w", err)
+		}
+		links = append(links, link)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+////////////////////////////////////////////////////////////////////////////
+// Query parameters
+////////////////////////////////////////////////////////////////////////////
+
+// A Redfish query parameter, see DSP0266 "Query parameters".  Services are
+// not required to support them all; check ProtocolFeaturesSupported in the
+// service root.
+type QueryOption struct {
+	Key   string
+	Value string
+}
+
+// $expand=.($levels=n): include subordinate resources (e.g. collection
+// members) inline, 'levels' deep.
+func Expand(levels int) QueryOption {
+	return QueryOption{"$expand", ".($levels=" + strconv.Itoa(levels) + ")"}
+}
+
+// $expand=*($levels=n): include both subordinate and Links resources inline.
+func ExpandAll(levels int) QueryOption {
+	return QueryOption{"$expand", "*($levels=" + strconv.Itoa(levels) + ")"}
+}
+
+// $select: only return the named properties.
+func Select(props ...string) QueryOption {
+	return QueryOption{"$select", strings.Join(props, ",")}
+}
+
+// $filter: only return collection members matching 'expr'.
+func Filter(expr string) QueryOption {
+	return QueryOption{"$filter", expr}
+}
+
+// $top: return at most 'n' collection members.
+func Top(n int) QueryOption {
+	return QueryOption{"$top", strconv.Itoa(n)}
+}
+
+// $skip: skip the first 'n' collection members.
+func Skip(n int) QueryOption {
+	return QueryOption{"$skip", strconv.Itoa(n)}
+}
+
+// Add 'opts' to the query of 'fullURL'.
+func addQuery(fullURL string, opts []QueryOption) string {
+	if len(opts) == 0 {
+		return fullURL
+	}
+	var b strings.Builder
+	b.WriteString(fullURL)
+	sep := "?"
+	if strings.Contains(fullURL, "?") {
+		sep = "&"
+	}
+	for _, opt := range opts {
+		b.WriteString(sep)
+		b.WriteString(opt.Key)
+		b.WriteString("=")
+		b.WriteString(escapeQueryValue(opt.Value))
+		sep = "&"
+	}
+	return b.String()
+}
+
+// Percent-encode only what has to be in a query value.  Some BMCs don't
+// decode the '$', '(' and '=' in values like ".($levels=1)", and RFC 3986
+// allows them unencoded anyway.
+func escapeQueryValue(s string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || '0' <= ch && ch <= '9' ||
+			strings.IndexByte("-._~!$'()*,;=:@/?", ch) >= 0 {
+			b.WriteByte(ch)
+		} else {
+			b.WriteByte('%')
+			b.WriteByte(hex[ch>>4])
+			b.WriteByte(hex[ch&0xf])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,341 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+// Package redfishtest provides a mock Redfish BMC for testing code that talks
+// to BMCs, without any hardware.
+//
+// The BMC runs in-process on an httptest.Server.  It serves a small default
+// service root with one system, chassis and manager, or any DMTF-style
+// mockup directory (as produced by the DMTF Redfish-Mockup-Creator, one
+// index.json per resource), and supports session and basic authentication,
+// PATCH, ComputerSystem.Reset/Chassis.Reset actions that change PowerState,
+// $expand of collection members, paging, and injected faults.
+//
+//  bmc := redfishtest.NewBMC()
+//  defer bmc.Close()
+//
+//  c := redfish.NewClient(bmc.URL(), redfishtest.DefaultUsername, redfishtest.DefaultPassword)
+//  ... code under test ...
+//
+//  if bmc.PowerState("/redfish/v1/Systems/Node0") != "Off" {
+//      ...
+//  }
+package redfishtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DefaultUsername = "root"
+const DefaultPassword = "initial0"
+
+const sessionsURI = "/redfish/v1/SessionService/Sessions"
+
+// A Resource is a Redfish resource as decoded from JSON.
+type Resource map[string]interface{}
+
+// BMC is a mock Redfish service.  Exported fields may be changed at any
+// time, e.g. to simulate a password change.
+type BMC struct {
+	Username    string
+	Password    string
+	RequireAuth bool          // If false, requests need no credentials at all
+	PageSize    int           // If > 0, collections are returned in pages of this size
+	PowerDelay  time.Duration // If > 0, power changes go through PoweringOn/PoweringOff for this long
+
+	srv       *httptest.Server
+	mu        sync.Mutex
+	resources map[string]Resource
+	sessions  map[string]string // token -> session URI
+	nextID    int
+	faults    []*Fault
+	requests  []string
+}
+
+// Create a BMC with the default resources (see DefaultResources()), not yet
+// listening.  Call Start() or StartTLS(), or use it directly as an
+// http.Handler.
+func NewUnstartedBMC() *BMC {
+	bmc := &BMC{
+		Username:    DefaultUsername,
+		Password:    DefaultPassword,
+		RequireAuth: true,
+		sessions:    map[string]string{},
+	}
+	bmc.SetResources(DefaultResources())
+	return bmc
+}
+
+// Create and start a BMC with the default resources, serving plain HTTP.
+func NewBMC() *BMC {
+	bmc := NewUnstartedBMC()
+	bmc.Start()
+	return bmc
+}
+
+// Create and start a BMC with the default resources, serving HTTPS with a
+// self-signed certificate (so clients need SkipTLSVerify, like with most
+// real BMCs).
+func NewTLSBMC() *BMC {
+	bmc := NewUnstartedBMC()
+	bmc.StartTLS()
+	return bmc
+}
+
+// Start serving plain HTTP.
+func (bmc *BMC) Start() {
+	bmc.srv = httptest.NewServer(bmc)
+}
+
+// Start serving HTTPS.
+func (bmc *BMC) StartTLS() {
+	bmc.srv = httptest.NewTLSServer(bmc)
+}
+
+// Stop serving.
+func (bmc *BMC) Close() {
+	if bmc.srv != nil {
+		bmc.srv.Close()
+	}
+}
+
+// Base URL of the BMC, e.g. "http://127.0.0.1:41234".
+func (bmc *BMC) URL() string {
+	return bmc.srv.URL
+}
+
+////////////////////////////////////////////////////////////////////////////
+// Resources
+////////////////////////////////////////////////////////////////////////////
+
+// Replace all resources with 'resources', keyed by URI.
+func (bmc *BMC) SetResources(resources map[string]Resource) {
+	bmc.mu.Lock()
+	defer bmc.mu.Unlock()
+	bmc.resources = map[string]Resource{}
+	for uri, res := range resources {
+		bmc.resources[cleanURI(uri)] = copyResource(res)
+	}
+}
+
+// Add or replace the resource at 'uri'.  Adding it to its collection's
+// Members, if needed, is up to the caller.
+func (bmc *BMC) SetResource(uri string, res Resource) {
+	bmc.mu.Lock()
+	defer bmc.mu.Unlock()
+	bmc.resources[cleanURI(uri)] = copyResource(res)
+}
+
+// Returns a copy of the resource at 'uri', or nil if there is none.
+func (bmc *BMC) GetResource(uri string) Resource {
+	bmc.mu.Lock()
+	defer bmc.mu.Unlock()
+	res, ok := bmc.resources[cleanURI(uri)]
+	if !ok {
+		return nil
+	}
+	return copyResource(res)
+}
+
+// Returns the PowerState of the system or chassis at 'uri'.
+func (bmc *BMC) PowerState(uri string) string {
+	state, _ := bmc.GetResource(uri)["PowerState"].(string)
+	return state
+}
+
+// Replace all resources with those from a DMTF-style mockup directory, where
+// each resource is in an index.json in a directory named after its URI,
+// e.g. <dir>/redfish/v1/Systems/1/index.json.  'dir' may also be the
+// redfish/v1 directory itself.
+func (bmc *BMC) LoadMockup(dir string) error {
+	resources, err := LoadMockup(dir)
+	if err != nil {
+		return err
+	}
+	bmc.SetResources(resources)
+	return nil
+}
+
+// Read the resources from a DMTF-style mockup directory (see
+// BMC.LoadMockup()), keyed by URI.
+func LoadMockup(dir string) (map[string]Resource, error) {
+	prefix := "/"
+	if _, err := os.Stat(filepath.Join(dir, "redfish")); err != nil {
+		prefix = "/redfish/v1/"
+	}
+	resources := map[string]Resource{}
+	err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || d.Name() != "index.json" {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, filepath.Dir(file))
+		uri := cleanURI(path.Join(prefix, filepath.ToSlash(rel)))
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		var res Resource
+		if err := json.Unmarshal(data, &res); err != nil {
+			return fmt.Errorf("unable to decode mockup %s: %w", file, err)
+		}
+		resources[uri] = res
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := resources["/redfish/v1"]; !ok {
+		return nil, fmt.Errorf("no service root found in mockup %s", dir)
+	}
+	return resources, nil
+}
+
+// Returns the resources of a minimal service: service root, SessionService,
+// one ComputerSystem (Node0, Off), one Chassis (Enclosure, On) and one
+// Manager (BMC), with their collections.
+func DefaultResources() map[string]Resource {
+	link := func(uri string) map[string]interface{} {
+		return map[string]interface{}{"@odata.id": uri}
+	}
+	collection := func(uri, odataType, name string, members ...string) Resource {
+		links := []interface{}{}
+		for _, m := range members {
+			links = append(links, link(m))
+		}
+		return Resource{
+			"@odata.id":           uri,
+			"@odata.type":         odataType,
+			"Name":                name,
+			"Members":             links,
+			"Members@odata.count": len(links),
+		}
+	}
+	resetAction := func(uri, action string) map[string]interface{} {
+		return map[string]interface{}{
+			"#" + action: map[string]interface{}{
+				"target": uri + "/Actions/" + action,
+				"ResetType@Redfish.AllowableValues": []interface{}{
+					"On", "ForceOff", "GracefulShutdown", "GracefulRestart", "ForceRestart", "PowerCycle",
+				},
+			},
+		}
+	}
+	status := map[string]interface{}{"State": "Enabled", "Health": "OK"}
+
+	return map[string]Resource{
+		"/redfish/v1": {
+			"@odata.id":      "/redfish/v1",
+			"@odata.type":    "#ServiceRoot.v1_15_0.ServiceRoot",
+			"Id":             "RootService",
+			"Name":           "Root Service",
+			"RedfishVersion": "1.17.0",
+			"Systems":        link("/redfish/v1/Systems"),
+			"Chassis":        link("/redfish/v1/Chassis"),
+			"Managers":       link("/redfish/v1/Managers"),
+			"SessionService": link("/redfish/v1/SessionService"),
+			"Links":          map[string]interface{}{"Sessions": link(sessionsURI)},
+		},
+		"/redfish/v1/SessionService": {
+			"@odata.id":   "/redfish/v1/SessionService",
+			"@odata.type": "#SessionService.v1_1_8.SessionService",
+			"Id":          "SessionService",
+			"Name":        "Session Service",
+			"Sessions":    link(sessionsURI),
+		},
+		"/redfish/v1/Systems": collection("/redfish/v1/Systems",
+			"#ComputerSystemCollection.ComputerSystemCollection", "Computer System Collection",
+			"/redfish/v1/Systems/Node0"),
+		"/redfish/v1/Systems/Node0": {
+			"@odata.id":   "/redfish/v1/Systems/Node0",
+			"@odata.type": "#ComputerSystem.v1_20_0.ComputerSystem",
+			"Id":          "Node0",
+			"Name":        "Node0",
+			"SystemType":  "Physical",
+			"PowerState":  "Off",
+			"Status":      status,
+			"Actions":     resetAction("/redfish/v1/Systems/Node0", "ComputerSystem.Reset"),
+		},
+		"/redfish/v1/Chassis": collection("/redfish/v1/Chassis",
+			"#ChassisCollection.ChassisCollection", "Chassis Collection",
+			"/redfish/v1/Chassis/Enclosure"),
+		"/redfish/v1/Chassis/Enclosure": {
+			"@odata.id":   "/redfish/v1/Chassis/Enclosure",
+			"@odata.type": "#Chassis.v1_23_0.Chassis",
+			"Id":          "Enclosure",
+			"Name":        "Enclosure",
+			"ChassisType": "Enclosure",
+			"PowerState":  "On",
+			"Status":      status,
+			"Actions":     resetAction("/redfish/v1/Chassis/Enclosure", "Chassis.Reset"),
+		},
+		"/redfish/v1/Managers": collection("/redfish/v1/Managers",
+			"#ManagerCollection.ManagerCollection", "Manager Collection",
+			"/redfish/v1/Managers/BMC"),
+		"/redfish/v1/Managers/BMC": {
+			"@odata.id":   "/redfish/v1/Managers/BMC",
+			"@odata.type": "#Manager.v1_17_0.Manager",
+			"Id":          "BMC",
+			"Name":        "Manager",
+			"ManagerType": "BMC",
+			"Status":      status,
+		},
+	}
+}
+
+func cleanURI(uri string) string {
+	if i := strings.IndexAny(uri, "?#"); i >= 0 {
+		uri = uri[:i]
+	}
+	uri = "/" + strings.Trim(uri, "/")
+	return uri
+}
+
+// Deep copy via JSON, so callers can't modify what the BMC serves.
+func copyResource(res Resource) Resource {
+	data, _ := json.Marshal(res)
+	var cp Resource
+	json.Unmarshal(data, &cp)
+	return cp
+}
+
+// Sorted list of all resource URIs, for debugging tests.
+func (bmc *BMC) ResourceURIs() []string {
+	bmc.mu.Lock()
+	defer bmc.mu.Unlock()
+	uris := []string{}
+	for uri := range bmc.resources {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	return uris
+}
@@ -0,0 +1,278 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package redfishtest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Cray-HPE/hms-base/v2/redfish"
+)
+
+func newTestClient(bmc *BMC) *redfish.Client {
+	c := redfish.NewClient(bmc.URL(), DefaultUsername, DefaultPassword)
+	c.SkipTLSVerify = true
+	return c
+}
+
+func TestBMCDefaultResources(t *testing.T) {
+	bmc := NewTLSBMC()
+	defer bmc.Close()
+	c := newTestClient(bmc)
+	ctx := context.Background()
+
+	var root redfish.Resource
+	if err := c.Get(ctx, redfish.ServiceRootURI, &root); err != nil {
+		t.Fatalf("Unable to get service root: %v", err)
+	}
+	if root.ID != "RootService" {
+		t.Errorf("Unexpected service root: %+v", root)
+	}
+
+	for _, uri := range []string{"/redfish/v1/Systems", "/redfish/v1/Chassis", "/redfish/v1/Managers"} {
+		links, err := c.GetMembers(ctx, uri)
+		if err != nil {
+			t.Fatalf("Unable to get %s: %v", uri, err)
+		}
+		if len(links) != 1 {
+			t.Errorf("Expected 1 member of %s, got %d", uri, len(links))
+		}
+	}
+
+	err := c.Get(ctx, "/redfish/v1/Systems/Nope", nil)
+	if !redfish.IsStatus(err, http.StatusNotFound) {
+		t.Errorf("Expected 404 for missing resource, got %v", err)
+	}
+}
+
+func TestBMCAuth(t *testing.T) {
+	bmc := NewBMC()
+	defer bmc.Close()
+	ctx := context.Background()
+
+	c := newTestClient(bmc)
+	c.Password = "wrong"
+	err := c.Get(ctx, "/redfish/v1/Systems", nil)
+	if !redfish.IsStatus(err, http.StatusUnauthorized) {
+		t.Errorf("Expected 401 with wrong password, got %v", err)
+	}
+
+	c = newTestClient(bmc)
+	if err := c.Get(ctx, "/redfish/v1/Systems", nil); err != nil {
+		t.Fatalf("Unable to get with session: %v", err)
+	}
+	links, err := c.GetMembers(ctx, "/redfish/v1/SessionService/Sessions")
+	if err != nil || len(links) != 1 {
+		t.Fatalf("Expected 1 session, got %v (%v)", links, err)
+	}
+	if err := c.Logout(ctx); err != nil {
+		t.Fatalf("Unable to log out: %v", err)
+	}
+	if res := bmc.GetResource(links[0].ODataID); res != nil {
+		t.Errorf("Session still exists after logout: %v", res)
+	}
+
+	c = newTestClient(bmc)
+	c.AuthMode = redfish.AuthBasic
+	if err := c.Get(ctx, "/redfish/v1/Systems", nil); err != nil {
+		t.Errorf("Unable to get with basic auth: %v", err)
+	}
+
+	bmc.RequireAuth = false
+	c = newTestClient(bmc)
+	c.AuthMode = redfish.AuthBasic
+	c.Password = "wrong"
+	if err := c.Get(ctx, "/redfish/v1/Systems", nil); err != nil {
+		t.Errorf("Unable to get with auth disabled: %v", err)
+	}
+}
+
+func TestBMCReset(t *testing.T) {
+	bmc := NewBMC()
+	defer bmc.Close()
+	c := newTestClient(bmc)
+	ctx := context.Background()
+	system := "/redfish/v1/Systems/Node0"
+
+	tests := []struct {
+		resetType string
+		expected  string
+	}{
+		{"On", "On"},
+		{"Nmi", "On"},
+		{"GracefulShutdown", "Off"},
+		{"PowerCycle", "On"},
+		{"ForceOff", "Off"},
+		{"PushPowerButton", "On"},
+	}
+	for _, tt := range tests {
+		body := map[string]string{"ResetType": tt.resetType}
+		if err := c.Post(ctx, system+"/Actions/ComputerSystem.Reset", body, nil); err != nil {
+			t.Fatalf("Unable to reset with %s: %v", tt.resetType, err)
+		}
+		if state := bmc.PowerState(system); state != tt.expected {
+			t.Errorf("Expected %s after %s, got %s", tt.expected, tt.resetType, state)
+		}
+	}
+
+	body := map[string]string{"ResetType": "Explode"}
+	err := c.Post(ctx, system+"/Actions/ComputerSystem.Reset", body, nil)
+	if !redfish.IsStatus(err, http.StatusBadRequest) {
+		t.Errorf("Expected 400 for bad ResetType, got %v", err)
+	}
+
+	bmc.PowerDelay = 50 * time.Millisecond
+	body = map[string]string{"ResetType": "ForceOff"}
+	if err := c.Post(ctx, "/redfish/v1/Chassis/Enclosure/Actions/Chassis.Reset", body, nil); err != nil {
+		t.Fatalf("Unable to reset chassis: %v", err)
+	}
+	if state := bmc.PowerState("/redfish/v1/Chassis/Enclosure"); state != "PoweringOff" {
+		t.Errorf("Expected PoweringOff, got %s", state)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if state := bmc.PowerState("/redfish/v1/Chassis/Enclosure"); state != "Off" {
+		t.Errorf("Expected Off after delay, got %s", state)
+	}
+}
+
+func TestBMCPatch(t *testing.T) {
+	bmc := NewBMC()
+	defer bmc.Close()
+	c := newTestClient(bmc)
+
+	body := map[string]string{"AssetTag": "rack1"}
+	if err := c.Patch(context.Background(), "/redfish/v1/Systems/Node0", body, nil); err != nil {
+		t.Fatalf("Unable to patch: %v", err)
+	}
+	res := bmc.GetResource("/redfish/v1/Systems/Node0")
+	if res["AssetTag"] != "rack1" || res["PowerState"] != "Off" {
+		t.Errorf("Unexpected resource after patch: %v", res)
+	}
+}
+
+func TestBMCPagingAndExpand(t *testing.T) {
+	bmc := NewBMC()
+	defer bmc.Close()
+	bmc.PageSize = 2
+	members := []interface{}{}
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		uri := "/redfish/v1/Systems/" + id
+		bmc.SetResource(uri, Resource{"@odata.id": uri, "Id": id})
+		members = append(members, map[string]interface{}{"@odata.id": uri})
+	}
+	bmc.SetResource("/redfish/v1/Systems", Resource{
+		"@odata.id": "/redfish/v1/Systems",
+		"Members":   members,
+	})
+	c := newTestClient(bmc)
+
+	var ids []string
+	err := c.ForEachMember(context.Background(), "/redfish/v1/Systems", func(m json.RawMessage) error {
+		var res redfish.Resource
+		if err := json.Unmarshal(m, &res); err != nil {
+			return err
+		}
+		ids = append(ids, res.ID)
+		return nil
+	}, redfish.Expand(1))
+	if err != nil {
+		t.Fatalf("Unable to walk collection: %v", err)
+	}
+	if len(ids) != 5 || ids[0] != "a" || ids[4] != "e" {
+		t.Errorf("Unexpected expanded members: %v", ids)
+	}
+}
+
+func TestBMCFaults(t *testing.T) {
+	bmc := NewBMC()
+	defer bmc.Close()
+	ctx := context.Background()
+	bmc.RequireAuth = false
+	c := newTestClient(bmc)
+
+	bmc.AddFault(Fault{Path: "/redfish/v1/Managers", StatusCode: http.StatusBadRequest, Times: 1})
+	if err := c.Get(ctx, "/redfish/v1/Managers/BMC", nil); !redfish.IsStatus(err, http.StatusBadRequest) {
+		t.Errorf("Expected injected 400, got %v", err)
+	}
+	if err := c.Get(ctx, "/redfish/v1/Managers/BMC", nil); err != nil {
+		t.Errorf("Fault applied more than once: %v", err)
+	}
+
+	// Retried by the client, so each of these succeeds on the second try.
+	bmc.ClearFaults()
+	bmc.AddFault(Fault{Method: http.MethodGet, StatusCode: http.StatusServiceUnavailable, Times: 1})
+	if err := c.Get(ctx, "/redfish/v1/Chassis", nil); err != nil {
+		t.Errorf("Unable to get after 503: %v", err)
+	}
+	bmc.AddFault(Fault{DropConnection: true, Times: 1})
+	if err := c.Get(ctx, "/redfish/v1/Chassis", nil); err != nil {
+		t.Errorf("Unable to get after dropped connection: %v", err)
+	}
+	bmc.AddFault(Fault{Delay: time.Second, Times: 1})
+	c.Timeout = 100 * time.Millisecond
+	if err := c.Get(ctx, "/redfish/v1/Chassis", nil); err != nil {
+		t.Errorf("Unable to get after slow response: %v", err)
+	}
+
+	count := 0
+	for _, req := range bmc.Requests() {
+		if req == "GET /redfish/v1/Chassis" {
+			count++
+		}
+	}
+	if count != 6 {
+		t.Errorf("Expected 6 requests for Chassis, got %d: %v", count, bmc.Requests())
+	}
+}
+
+func TestBMCLoadMockup(t *testing.T) {
+	bmc := NewUnstartedBMC()
+	if err := bmc.LoadMockup("testdata/mockup"); err != nil {
+		t.Fatalf("Unable to load mockup: %v", err)
+	}
+	bmc.Start()
+	defer bmc.Close()
+	bmc.RequireAuth = false
+	c := newTestClient(bmc)
+
+	var system struct {
+		redfish.Resource
+		Manufacturer string
+	}
+	if err := c.Get(context.Background(), "/redfish/v1/Systems/1", &system); err != nil {
+		t.Fatalf("Unable to get mockup system: %v", err)
+	}
+	if system.Manufacturer != "Contoso" || bmc.PowerState("/redfish/v1/Systems/1") != "On" {
+		t.Errorf("Unexpected mockup system: %+v", system)
+	}
+
+	if _, err := LoadMockup("testdata/mockup/redfish/v1"); err != nil {
+		t.Errorf("Unable to load mockup from redfish/v1: %v", err)
+	}
+	if _, err := LoadMockup("testdata"); err == nil {
+		t.Errorf("Expected error loading a directory without a service root")
+	}
+}
@@ -0,0 +1,467 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package redfishtest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////
+// Faults
+////////////////////////////////////////////////////////////////////////////
+
+// A Fault changes how the BMC responds to matching requests.  A request
+// matches if its method and the start of its path match (empty matches
+// anything).  The fault is applied to the first Times matching requests, or
+// to all of them if Times is 0.
+//
+// The response is delayed by Delay (or until the client gives up), then the
+// connection is dropped without a response if DropConnection is set, or
+// the request fails with StatusCode if that is set.  Otherwise the request
+// is handled normally.
+type Fault struct {
+	Method         string
+	Path           string
+	Times          int
+	Delay          time.Duration
+	StatusCode     int
+	RetryAfter     int // Seconds, sent with StatusCode if > 0
+	DropConnection bool
+
+	hits int
+}
+
+// Add a fault.  Faults are checked in the order added and only the first
+// that matches a request applies.
+func (bmc *BMC) AddFault(f Fault) {
+	bmc.mu.Lock()
+	defer bmc.mu.Unlock()
+	bmc.faults = append(bmc.faults, &f)
+}
+
+// Remove all faults.
+func (bmc *BMC) ClearFaults() {
+	bmc.mu.Lock()
+	defer bmc.mu.Unlock()
+	bmc.faults = nil
+}
+
+// Returns the fault that applies to 'r', if any, counting it as used.
+func (bmc *BMC) matchFault(r *http.Request) *Fault {
+	bmc.mu.Lock()
+	defer bmc.mu.Unlock()
+	for _, f := range bmc.faults {
+		if f.Method != "" && !strings.EqualFold(f.Method, r.Method) {
+			continue
+		}
+		if !strings.HasPrefix(r.URL.Path, f.Path) {
+			continue
+		}
+		if f.Times > 0 && f.hits >= f.Times {
+			continue
+		}
+		f.hits++
+		cp := *f
+		return &cp
+	}
+	return nil
+}
+
+// Returns true if the request was finished off by the fault.
+func applyFault(f *Fault, w http.ResponseWriter, r *http.Request) bool {
+	if f.Delay > 0 {
+		timer := time.NewTimer(f.Delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-r.Context().Done():
+			return true
+		}
+	}
+	if f.DropConnection {
+		if hj, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hj.Hijack(); err == nil {
+				conn.Close()
+				return true
+			}
+		}
+		panic(http.ErrAbortHandler)
+	}
+	if f.StatusCode != 0 {
+		if f.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(f.RetryAfter))
+		}
+		writeError(w, f.StatusCode, "Base.1.8.GeneralError", "Injected fault")
+		return true
+	}
+	return false
+}
+
+// Returns each request received so far as "METHOD /path?query".
+func (bmc *BMC) Requests() []string {
+	bmc.mu.Lock()
+	defer bmc.mu.Unlock()
+	return append([]string{}, bmc.requests...)
+}
+
+////////////////////////////////////////////////////////////////////////////
+// Request handling
+////////////////////////////////////////////////////////////////////////////
+
+func (bmc *BMC) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bmc.mu.Lock()
+	bmc.requests = append(bmc.requests, r.Method+" "+r.URL.RequestURI())
+	bmc.mu.Unlock()
+
+	if f := bmc.matchFault(r); f != nil && applyFault(f, w, r) {
+		return
+	}
+
+	uri := cleanURI(r.URL.Path)
+	if uri == sessionsURI && r.Method == http.MethodPost {
+		bmc.login(w, r)
+		return
+	}
+	// The service root is always readable, as the spec requires.
+	if uri != "/redfish/v1" && !bmc.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "Base.1.8.NoValidSession",
+			"There is no valid session established with the implementation.")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		bmc.get(w, r, uri)
+	case http.MethodPatch:
+		bmc.patch(w, r, uri)
+	case http.MethodPost:
+		bmc.post(w, r, uri)
+	case http.MethodDelete:
+		bmc.delete(w, r, uri)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Base.1.8.OperationNotAllowed",
+			"The HTTP method is not allowed on this resource.")
+	}
+}
+
+func (bmc *BMC) authorized(r *http.Request) bool {
+	bmc.mu.Lock()
+	defer bmc.mu.Unlock()
+	if !bmc.RequireAuth {
+		return true
+	}
+	if _, ok := bmc.sessions[r.Header.Get("X-Auth-Token")]; ok {
+		return true
+	}
+	user, pass, ok := r.BasicAuth()
+	return ok && user == bmc.Username && pass == bmc.Password
+}
+
+func (bmc *BMC) login(w http.ResponseWriter, r *http.Request) {
+	var creds struct {
+		UserName string
+		Password string
+	}
+	if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
+		writeError(w, http.StatusBadRequest, "Base.1.8.MalformedJSON",
+			"The request body submitted was malformed JSON.")
+		return
+	}
+
+	bmc.mu.Lock()
+	defer bmc.mu.Unlock()
+	if creds.UserName != bmc.Username || creds.Password != bmc.Password {
+		writeError(w, http.StatusUnauthorized, "Base.1.8.ResourceAtUriUnauthorized",
+			"While accessing the resource at "+sessionsURI+", the service received an authorization error.")
+		return
+	}
+	bmc.nextID++
+	id := strconv.Itoa(bmc.nextID)
+	uri := sessionsURI + "/" + id
+	token := newToken()
+	bmc.sessions[token] = uri
+	session := Resource{
+		"@odata.id":   uri,
+		"@odata.type": "#Session.v1_7_0.Session",
+		"Id":          id,
+		"Name":        "User Session",
+		"UserName":    creds.UserName,
+	}
+	bmc.resources[uri] = session
+
+	w.Header().Set("X-Auth-Token", token)
+	w.Header().Set("Location", uri)
+	writeJSON(w, http.StatusCreated, session)
+}
+
+func newToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (bmc *BMC) get(w http.ResponseWriter, r *http.Request, uri string) {
+	bmc.mu.Lock()
+	res, ok := bmc.resources[uri]
+	if uri == sessionsURI {
+		res, ok = bmc.sessionCollection(), true
+	}
+	if ok {
+		res = copyResource(res)
+		bmc.expandAndPage(res, r)
+	}
+	bmc.mu.Unlock()
+
+	if !ok {
+		writeNotFound(w, uri)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (bmc *BMC) sessionCollection() Resource {
+	uris := []string{}
+	for _, uri := range bmc.sessions {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	members := []interface{}{}
+	for _, uri := range uris {
+		members = append(members, map[string]interface{}{"@odata.id": uri})
+	}
+	return Resource{
+		"@odata.id":           sessionsURI,
+		"@odata.type":         "#SessionCollection.SessionCollection",
+		"Name":                "Session Collection",
+		"Members":             members,
+		"Members@odata.count": len(members),
+	}
+}
+
+// Apply $expand (one level of collection members only), $skip, $top and
+// PageSize to a copy of a collection.
+func (bmc *BMC) expandAndPage(res Resource, r *http.Request) {
+	members, ok := res["Members"].([]interface{})
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+
+	skip, _ := strconv.Atoi(query.Get("$skip"))
+	if skip > len(members) {
+		skip = len(members)
+	}
+	end := len(members)
+	if top, err := strconv.Atoi(query.Get("$top")); err == nil && skip+top < end {
+		end = skip + top
+	}
+	if bmc.PageSize > 0 && skip+bmc.PageSize < end {
+		end = skip + bmc.PageSize
+		next := query
+		next.Set("$skip", strconv.Itoa(end))
+		res["Members@odata.nextLink"] = cleanURI(r.URL.Path) + "?" + next.Encode()
+	}
+	members = members[skip:end]
+
+	if query.Get("$expand") != "" {
+		for i, m := range members {
+			link, _ := m.(map[string]interface{})
+			id, _ := link["@odata.id"].(string)
+			if full, ok := bmc.resources[cleanURI(id)]; ok {
+				members[i] = copyResource(full)
+			}
+		}
+	}
+	res["Members"] = members
+}
+
+func (bmc *BMC) patch(w http.ResponseWriter, r *http.Request, uri string) {
+	var changes Resource
+	if err := json.NewDecoder(r.Body).Decode(&changes); err != nil {
+		writeError(w, http.StatusBadRequest, "Base.1.8.MalformedJSON",
+			"The request body submitted was malformed JSON.")
+		return
+	}
+
+	bmc.mu.Lock()
+	res, ok := bmc.resources[uri]
+	if ok {
+		for key, val := range changes {
+			res[key] = val
+		}
+		res = copyResource(res)
+	}
+	bmc.mu.Unlock()
+
+	if !ok {
+		writeNotFound(w, uri)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (bmc *BMC) delete(w http.ResponseWriter, r *http.Request, uri string) {
+	bmc.mu.Lock()
+	defer bmc.mu.Unlock()
+	if !strings.HasPrefix(uri, sessionsURI+"/") {
+		writeError(w, http.StatusMethodNotAllowed, "Base.1.8.OperationNotAllowed",
+			"The HTTP method is not allowed on this resource.")
+		return
+	}
+	for token, sessionURI := range bmc.sessions {
+		if sessionURI == uri {
+			delete(bmc.sessions, token)
+			delete(bmc.resources, uri)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeNotFound(w, uri)
+}
+
+////////////////////////////////////////////////////////////////////////////
+// Actions
+////////////////////////////////////////////////////////////////////////////
+
+// PowerState after each ResetType, given the current one.
+var resetPowerStates = map[string]func(current string) string{
+	"On":               func(string) string { return "On" },
+	"ForceOn":          func(string) string { return "On" },
+	"ForceOff":         func(string) string { return "Off" },
+	"GracefulShutdown": func(string) string { return "Off" },
+	"GracefulRestart":  func(string) string { return "On" },
+	"ForceRestart":     func(string) string { return "On" },
+	"PowerCycle":       func(string) string { return "On" },
+	"Nmi":              func(cur string) string { return cur },
+	"PushPowerButton": func(cur string) string {
+		if cur == "On" {
+			return "Off"
+		}
+		return "On"
+	},
+}
+
+func (bmc *BMC) post(w http.ResponseWriter, r *http.Request, uri string) {
+	for _, action := range []string{"/Actions/ComputerSystem.Reset", "/Actions/Chassis.Reset"} {
+		if strings.HasSuffix(uri, action) {
+			bmc.reset(w, r, strings.TrimSuffix(uri, action))
+			return
+		}
+	}
+	bmc.mu.Lock()
+	_, ok := bmc.resources[uri]
+	bmc.mu.Unlock()
+	if !ok {
+		writeNotFound(w, uri)
+		return
+	}
+	writeError(w, http.StatusMethodNotAllowed, "Base.1.8.OperationNotAllowed",
+		"The HTTP method is not allowed on this resource.")
+}
+
+func (bmc *BMC) reset(w http.ResponseWriter, r *http.Request, uri string) {
+	var params struct {
+		ResetType string
+	}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		writeError(w, http.StatusBadRequest, "Base.1.8.MalformedJSON",
+			"The request body submitted was malformed JSON.")
+		return
+	}
+	nextState, ok := resetPowerStates[params.ResetType]
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Base.1.8.ActionParameterValueNotInList",
+			fmt.Sprintf("The value '%s' for the parameter ResetType is not in the list of acceptable values.",
+				params.ResetType))
+		return
+	}
+
+	bmc.mu.Lock()
+	defer bmc.mu.Unlock()
+	res, ok := bmc.resources[uri]
+	if !ok {
+		writeNotFound(w, uri)
+		return
+	}
+	cur, _ := res["PowerState"].(string)
+	next := nextState(cur)
+	if bmc.PowerDelay > 0 && next != cur {
+		if next == "On" {
+			res["PowerState"] = "PoweringOn"
+		} else {
+			res["PowerState"] = "PoweringOff"
+		}
+		time.AfterFunc(bmc.PowerDelay, func() {
+			bmc.mu.Lock()
+			defer bmc.mu.Unlock()
+			if res, ok := bmc.resources[uri]; ok {
+				res["PowerState"] = next
+			}
+		})
+	} else {
+		res["PowerState"] = next
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+////////////////////////////////////////////////////////////////////////////
+// Responses
+////////////////////////////////////////////////////////////////////////////
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("OData-Version", "4.0")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// Write a Redfish error response.
+func writeError(w http.ResponseWriter, status int, messageID, msg string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":    messageID,
+			"message": msg,
+			"@Message.ExtendedInfo": []interface{}{
+				map[string]interface{}{
+					"@odata.type": "#Message.v1_1_1.Message",
+					"MessageId":   messageID,
+					"Message":     msg,
+					"Severity":    "Critical",
+				},
+			},
+		},
+	})
+}
+
+func writeNotFound(w http.ResponseWriter, uri string) {
+	writeError(w, http.StatusNotFound, "Base.1.8.ResourceMissingAtURI",
+		"The resource at the URI "+uri+" was not found.")
+}
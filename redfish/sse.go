@@ -0,0 +1,199 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package redfish
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Delays between attempts to reconnect a dropped SSE stream.
+const sseMinRetryDelay = time.Second
+const sseMaxRetryDelay = 30 * time.Second
+
+// Read events from the Server-Sent Events stream of the service 'c' talks
+// to, and dispatch them like POSTed events, until 'ctx' is done.  The
+// stream URI comes from the EventService's ServerSentEventUri; 'filter'
+// (e.g. "RegistryPrefix eq 'ResourceEvent'"), if not empty, is passed as
+// its $filter.
+//
+// Dropped streams are reconnected, with backoff, asking the service to
+// resume after the last event received.  Returns ctx.Err() when 'ctx' is
+// done, or an error if the service doesn't support SSE.
+func (l *EventListener) ListenSSE(ctx context.Context, c *Client, filter string) error {
+	var svc struct {
+		ServerSentEventURI string `json:"ServerSentEventUri"`
+	}
+	if err := c.Get(ctx, EventServiceURI, &svc); err != nil {
+		return fmt.Errorf("unable to get Redfish EventService: %w", err)
+	}
+	if svc.ServerSentEventURI == "" {
+		return fmt.Errorf("Redfish service at %s does not support SSE", c.Endpoint)
+	}
+	uri := svc.ServerSentEventURI
+	if filter != "" {
+		uri = addQuery(uri, []QueryOption{Filter(filter)})
+	}
+
+	lastID := ""
+	delay := sseMinRetryDelay
+	for {
+		start := time.Now()
+		err := c.StreamEvents(ctx, uri, lastID, func(id string, ev *Event) error {
+			if id != "" {
+				lastID = id
+			}
+			l.dispatch(ev, c.Endpoint)
+			return nil
+		})
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if time.Since(start) > sseMaxRetryDelay {
+			delay = sseMinRetryDelay
+		}
+		l.logger().Warn("Redfish SSE stream ended, reconnecting",
+			"endpoint", c.Endpoint, "error", err, "delay", delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		if delay *= 2; delay > sseMaxRetryDelay {
+			delay = sseMaxRetryDelay
+		}
+	}
+}
+
+// Open the SSE stream at 'uri' and call 'fn' with each event on it, along
+// with its SSE id (if any), until the stream ends, 'fn' returns an error,
+// or 'ctx' is done.  'lastID', if not empty, is sent as Last-Event-ID so
+// the service can resend events missed since then.
+//
+// Most callers want EventListener.ListenSSE() instead.
+func (c *Client) StreamEvents(ctx context.Context, uri, lastID string, fn func(id string, ev *Event) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	body, err := c.openStream(ctx, c.URL(uri), lastID)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Cancel first, or closing would wait to drain an endless stream.
+		cancel()
+		body.Close()
+	}()
+	return readSSE(body, func(id, data string) error {
+		var ev Event
+		if err := json.Unmarshal([]byte(data), &ev); err != nil {
+			return fmt.Errorf("malformed Redfish SSE event: %w", err)
+		}
+		return fn(id, &ev)
+	})
+}
+
+func (c *Client) openStream(ctx context.Context, fullURL, lastID string) (io.ReadCloser, error) {
+	var token string
+	if c.AuthMode == AuthSession {
+		var err error
+		if token, err = c.sessionToken(ctx, ""); err != nil {
+			return nil, err
+		}
+	}
+	for {
+		request := c.newRequest(withoutCache(ctx), http.MethodGet, fullURL, nil, token)
+		request.Header.Set("Accept", "text/event-stream")
+		if lastID != "" {
+			request.Header.Set("Last-Event-ID", lastID)
+		}
+		// The stream is read as long as it lasts.
+		request.Timeout = 0
+		request.MaxResponseBytes = 0
+
+		resp, err := request.DoHTTPResponse()
+		err = statusError(err)
+		if IsStatus(err, http.StatusUnauthorized) && token != "" {
+			// Session expired; log in again and retry once.
+			stale := token
+			if token, err = c.sessionToken(ctx, stale); err != nil {
+				return nil, err
+			}
+			if token != stale {
+				continue
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		return resp.Body, nil
+	}
+}
+
+// Read a text/event-stream, calling 'fn' with the id and data of each
+// message.  Comments and event types are ignored.  Returns nil at the end of
+// the stream, or the first error from reading or from 'fn'.
+func readSSE(r io.Reader, fn func(id, data string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), DefaultMaxEventBytes)
+	var id string
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if len(data) > 0 {
+				if err := fn(id, strings.Join(data, "\n")); err != nil {
+					return err
+				}
+			}
+			data = data[:0]
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "data":
+			data = append(data, value)
+		case "id":
+			if !strings.Contains(value, "\x00") {
+				id = value
+			}
+		}
+	}
+	return scanner.Err()
+}
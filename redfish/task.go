@@ -0,0 +1,241 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package redfish
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Redfish TaskState values.
+const (
+	TaskStateNew         = "New"
+	TaskStateStarting    = "Starting"
+	TaskStateRunning     = "Running"
+	TaskStateSuspended   = "Suspended"
+	TaskStateInterrupted = "Interrupted"
+	TaskStatePending     = "Pending"
+	TaskStateStopping    = "Stopping"
+	TaskStateCompleted   = "Completed"
+	TaskStateKilled      = "Killed"
+	TaskStateException   = "Exception"
+	TaskStateService     = "Service"
+	TaskStateCancelling  = "Cancelling"
+	TaskStateCancelled   = "Cancelled"
+)
+
+// A Redfish Task, as returned by a task monitor while the operation it
+// tracks is still in progress.  Not all services fill in all fields; in
+// particular PercentComplete is often missing.
+type Task struct {
+	Resource
+	TaskState       string        `json:"TaskState,omitempty"`
+	TaskStatus      string        `json:"TaskStatus,omitempty"` // OK, Warning or Critical
+	PercentComplete *int          `json:"PercentComplete,omitempty"`
+	StartTime       string        `json:"StartTime,omitempty"`
+	EndTime         string        `json:"EndTime,omitempty"`
+	TaskMonitor     string        `json:"TaskMonitor,omitempty"`
+	Messages        []MessageInfo `json:"Messages,omitempty"`
+}
+
+// Returns true if the task has finished, successfully or not.
+func (t *Task) IsDone() bool {
+	switch t.TaskState {
+	case TaskStateCompleted, TaskStateKilled, TaskStateException, TaskStateCancelled:
+		return true
+	}
+	return false
+}
+
+// Returns true if the task finished successfully.
+func (t *Task) Succeeded() bool {
+	return t.TaskState == TaskStateCompleted && t.TaskStatus != "Critical"
+}
+
+// Returned when a task finishes unsuccessfully, i.e. a task monitor
+// returns a Task that is done but did not succeed.  Operations that fail
+// with an HTTP error status instead produce an *Error as usual.
+type TaskError struct {
+	Task *Task
+}
+
+func (e *TaskError) Error() string {
+	msg := fmt.Sprintf("Redfish task %s ended in state %s", e.Task.ODataID, e.Task.TaskState)
+	if e.Task.TaskStatus != "" {
+		msg += " with status " + e.Task.TaskStatus
+	}
+	if len(e.Task.Messages) > 0 && e.Task.Messages[0].Message != "" {
+		msg += ": " + e.Task.Messages[0].Message
+	}
+	return msg
+}
+
+// Options for DoAsync() and WaitTask().  A nil *TaskOptions means the
+// defaults.
+type TaskOptions struct {
+	// Delay before the first poll, doubled after each one up to
+	// MaxPollInterval.  A Retry-After header from the service overrides
+	// it (up to MaxPollInterval).  Defaults to 1s.
+	PollInterval time.Duration
+
+	// Longest delay between polls.  Defaults to 30s.
+	MaxPollInterval time.Duration
+
+	// Called with the task after each poll that finds it still in
+	// progress.
+	OnProgress func(task *Task)
+}
+
+const defaultTaskPollInterval = time.Second
+const defaultTaskMaxPollInterval = 30 * time.Second
+
+// Make a request like Do(), and if the service accepts it for asynchronous
+// processing (202 Accepted), wait for it to finish as WaitTask() does.
+// Either way the result is the final response of the operation.
+//
+// There is no timeout beyond the one (if any) of 'ctx'.
+func (c *Client) DoAsync(ctx context.Context, method, uri string, body interface{}, opts *TaskOptions) (*Response, error) {
+	resp, err := c.Do(ctx, method, uri, body)
+	if err != nil || resp.StatusCode != http.StatusAccepted {
+		return resp, err
+	}
+
+	monitor := resp.Header.Get("Location")
+	if monitor == "" {
+		var task Task
+		resp.Decode(&task)
+		if monitor = task.TaskMonitor; monitor == "" {
+			monitor = task.ODataID
+		}
+	}
+	if monitor == "" {
+		return nil, fmt.Errorf("Redfish request accepted but no task monitor given")
+	}
+	return c.waitTask(ctx, monitor, opts, retryAfter(resp.Header))
+}
+
+// Poll the task monitor at 'monitor' (usually the Location returned with a
+// 202 Accepted) until the task it tracks is done, and return the final
+// response.
+//
+// Per the Redfish spec the monitor responds 202 while the task is running,
+// and with the operation's own response once it is done.  Some services
+// instead serve the Task resource itself, with 200, all along; the task is
+// then done when its TaskState says so, and a TaskError is returned if it
+// did not succeed.
+//
+// If 'ctx' expires first, the error wraps its error (e.g.
+// context.DeadlineExceeded).
+func (c *Client) WaitTask(ctx context.Context, monitor string, opts *TaskOptions) (*Response, error) {
+	return c.waitTask(ctx, monitor, opts, -1)
+}
+
+func (c *Client) waitTask(ctx context.Context, monitor string, opts *TaskOptions, delay time.Duration) (*Response, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if opts == nil {
+		opts = &TaskOptions{}
+	}
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = defaultTaskPollInterval
+	}
+	maxInterval := opts.MaxPollInterval
+	if maxInterval <= 0 {
+		maxInterval = defaultTaskMaxPollInterval
+	}
+	if delay > maxInterval {
+		delay = maxInterval
+	}
+
+	for {
+		if delay < 0 {
+			delay = interval
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("timed out waiting for Redfish task %s: %w", monitor, ctx.Err())
+		case <-timer.C:
+		}
+		if interval *= 2; interval > maxInterval {
+			interval = maxInterval
+		}
+
+		// The task changes from one poll to the next, so a cached copy is
+		// no use.
+		resp, err := c.Do(withoutCache(ctx), http.MethodGet, monitor, nil)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("timed out waiting for Redfish task %s: %w", monitor, ctx.Err())
+			}
+			return nil, err
+		}
+
+		var task Task
+		isTask := json.Unmarshal(resp.Body, &task) == nil &&
+			(task.TaskState != "" || strings.Contains(task.ODataType, "#Task."))
+		if resp.StatusCode != http.StatusAccepted && !isTask {
+			return resp, nil
+		}
+		if task.IsDone() {
+			if !task.Succeeded() {
+				return resp, &TaskError{Task: &task}
+			}
+			return resp, nil
+		}
+		if opts.OnProgress != nil && isTask {
+			opts.OnProgress(&task)
+		}
+		delay = retryAfter(resp.Header)
+		if delay > maxInterval {
+			delay = maxInterval
+		}
+	}
+}
+
+// Returns the delay requested by a Retry-After header (in seconds or as an
+// HTTP date), or -1 if there is none.
+func retryAfter(h http.Header) time.Duration {
+	val := h.Get("Retry-After")
+	if val == "" {
+		return -1
+	}
+	if secs, err := strconv.Atoi(val); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(val); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+		return 0
+	}
+	return -1
+}
@@ -0,0 +1,223 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package redfish
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	base "github.com/Cray-HPE/hms-base/v2"
+)
+
+// Serves a task monitor at /tasks/1 that replies with each of 'replies' in
+// turn, repeating the last one.  POSTs to /action start the task.
+func newTaskServer(t *testing.T, replies ...func(w http.ResponseWriter)) (*Client, *int) {
+	var mu sync.Mutex
+	polls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/action":
+			w.Header().Set("Location", "/tasks/1")
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusAccepted)
+		case "/tasks/1":
+			i := polls
+			if i >= len(replies) {
+				i = len(replies) - 1
+			}
+			polls++
+			replies[i](w)
+		default:
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"Id":"sync"}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	c := NewClient(srv.URL, "root", "secret")
+	c.AuthMode = AuthBasic
+	return c, &polls
+}
+
+func taskReply(status int, state string, percent int) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"@odata.id":       "/redfish/v1/TaskService/Tasks/1",
+			"@odata.type":     "#Task.v1_7_0.Task",
+			"TaskState":       state,
+			"TaskStatus":      "OK",
+			"PercentComplete": percent,
+		})
+	}
+}
+
+func TestDoAsync(t *testing.T) {
+	c, polls := newTaskServer(t,
+		taskReply(http.StatusAccepted, TaskStateRunning, 10),
+		taskReply(http.StatusAccepted, TaskStateRunning, 60),
+		func(w http.ResponseWriter) { w.Write([]byte(`{"Id":"done"}`)) },
+	)
+
+	var progress []int
+	opts := &TaskOptions{
+		PollInterval: 10 * time.Millisecond,
+		OnProgress: func(task *Task) {
+			progress = append(progress, *task.PercentComplete)
+		},
+	}
+	resp, err := c.DoAsync(context.Background(), http.MethodPost, "/action", nil, opts)
+	if err != nil {
+		t.Fatalf("DoAsync failed: %v", err)
+	}
+	var res Resource
+	resp.Decode(&res)
+	if res.ID != "done" || *polls != 3 {
+		t.Errorf("Unexpected result %+v after %d polls", res, *polls)
+	}
+	if len(progress) != 2 || progress[0] != 10 || progress[1] != 60 {
+		t.Errorf("Unexpected progress: %v", progress)
+	}
+
+	// Requests that complete synchronously are passed through.
+	resp, err = c.DoAsync(context.Background(), http.MethodPost, "/sync", nil, nil)
+	if err != nil || resp.StatusCode != http.StatusOK || *polls != 3 {
+		t.Errorf("Unexpected synchronous result %v, %v", resp, err)
+	}
+}
+
+// A huge Retry-After with the 202 is capped at MaxPollInterval, like those
+// from the task monitor.
+func TestDoAsyncRetryAfterCapped(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/action" {
+			w.Header().Set("Location", "/tasks/1")
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		taskReply(http.StatusOK, TaskStateCompleted, 100)(w)
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, "root", "secret")
+	c.AuthMode = AuthBasic
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	opts := &TaskOptions{PollInterval: 10 * time.Millisecond, MaxPollInterval: 20 * time.Millisecond}
+	if _, err := c.DoAsync(ctx, http.MethodPost, "/action", nil, opts); err != nil {
+		t.Errorf("DoAsync failed: %v", err)
+	}
+}
+
+func TestWaitTaskResource(t *testing.T) {
+	c, _ := newTaskServer(t,
+		taskReply(http.StatusOK, TaskStateRunning, 10),
+		taskReply(http.StatusOK, TaskStateCompleted, 100),
+	)
+	opts := &TaskOptions{PollInterval: 10 * time.Millisecond}
+	if _, err := c.WaitTask(context.Background(), "/tasks/1", opts); err != nil {
+		t.Errorf("WaitTask failed: %v", err)
+	}
+
+	// A cached copy of the running task must not be polled until it
+	// expires.
+	c, polls := newTaskServer(t,
+		taskReply(http.StatusOK, TaskStateRunning, 10),
+		taskReply(http.StatusOK, TaskStateCompleted, 100),
+	)
+	c.Cache = base.NewResponseCache(10, time.Hour)
+	var task Task
+	if err := c.Get(context.Background(), "/tasks/1", &task); err != nil {
+		t.Fatalf("GET of task failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := c.WaitTask(ctx, "/tasks/1", opts); err != nil || *polls != 2 {
+		t.Errorf("WaitTask with cache failed after %d polls: %v", *polls, err)
+	}
+
+	c, _ = newTaskServer(t,
+		taskReply(http.StatusOK, TaskStateRunning, 10),
+		taskReply(http.StatusOK, TaskStateException, 20),
+	)
+	_, err := c.WaitTask(context.Background(), "/tasks/1", opts)
+	var taskErr *TaskError
+	if !errors.As(err, &taskErr) || taskErr.Task.TaskState != TaskStateException {
+		t.Errorf("Expected TaskError for Exception, got %v", err)
+	}
+}
+
+func TestWaitTaskFailed(t *testing.T) {
+	c, _ := newTaskServer(t, func(w http.ResponseWriter) {
+		writeTestError(w, http.StatusBadRequest, "Base.1.8.GeneralError", "Update failed")
+	})
+	opts := &TaskOptions{PollInterval: 10 * time.Millisecond}
+	_, err := c.WaitTask(context.Background(), "/tasks/1", opts)
+	if !IsStatus(err, http.StatusBadRequest) {
+		t.Errorf("Expected 400 from task monitor, got %v", err)
+	}
+}
+
+func TestWaitTaskTimeout(t *testing.T) {
+	c, _ := newTaskServer(t, taskReply(http.StatusAccepted, TaskStateRunning, 10))
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	opts := &TaskOptions{PollInterval: 10 * time.Millisecond, MaxPollInterval: 20 * time.Millisecond}
+	_, err := c.DoAsync(ctx, http.MethodPost, "/action", nil, opts)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"", -1},
+		{"5", 5 * time.Second},
+		{"soon", -1},
+		{"Wed, 21 Oct 2015 07:28:00 GMT", 0},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		if tt.value != "" {
+			h.Set("Retry-After", tt.value)
+		}
+		if d := retryAfter(h); d != tt.expected {
+			t.Errorf("Retry-After %q: expected %v, got %v", tt.value, tt.expected, d)
+		}
+	}
+	h := http.Header{"Retry-After": {time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)}}
+	if d := retryAfter(h); d < 59*time.Minute || d > time.Hour {
+		t.Errorf("Unexpected delay for future date: %v", d)
+	}
+}
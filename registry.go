@@ -0,0 +1,559 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package base
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v3"
+)
+
+// Prefix of the environment variables ServiceRegistry.LoadEnv() reads.
+// For a service "cray-smd":
+//
+//  HMS_SERVICE_CRAY_SMD_URLS=http://cray-smd,http://cray-smd-2.services:27779
+//  HMS_SERVICE_CRAY_SMD_HEALTH_PATH=/hsm/v2/service/ready
+const ServiceEnvPrefix = "HMS_SERVICE_"
+
+// How long an endpoint that refused a connection is passed over, unless a
+// health check finds it healthy sooner.
+const DefaultFailoverCooldown = 30 * time.Second
+
+// Endpoints of a logical service, as set with SetService() or read from a
+// registry file, which is a JSON object of these by service name:
+//
+//  {
+//      "cray-smd": {
+//          "URLs": ["http://cray-smd", "http://cray-smd-2.services:27779"],
+//          "HealthPath": "/hsm/v2/service/ready"
+//      }
+//  }
+//
+// or the same in YAML:
+//
+//  cray-smd:
+//    URLs:
+//      - http://cray-smd
+//      - http://cray-smd-2.services:27779
+//    HealthPath: /hsm/v2/service/ready
+type ServiceConfig struct {
+	URLs       []string `json:"URLs" yaml:"URLs"`                                 // Base URLs, in order of preference
+	HealthPath string   `json:"HealthPath,omitempty" yaml:"HealthPath,omitempty"` // Path to GET to check health, "" for no checks
+}
+
+// ServiceRegistry maps logical service names, like "cray-smd", to the base
+// URLs they can be reached at, and keeps track of which of those are
+// healthy.  It can be used to build URLs (see URL()), but it is most useful
+// installed in an HTTPClient with SetServiceRegistry(): requests made with
+// the client to a URL whose host is a registered service name, e.g.
+// "http://cray-smd/hsm/v2/State/Components", are then sent to the first
+// healthy endpoint of the service, failing over to the next if a connection
+// can't be made.  Requests to other hosts are not affected.
+//
+//  r := base.NewServiceRegistry()
+//  if err := r.LoadEnv(); err != nil {
+//      ...
+//  }
+//  base.GetSharedHTTPClient().SetServiceRegistry(r)
+//  r.StartHealthChecks(30 * time.Second)
+//  defer r.Stop()
+//
+// Endpoints with a HealthPath are checked every interval once health checks
+// are started.  Independently of that, an endpoint that refuses a
+// connection is passed over for FailoverCooldown.  If no endpoint is
+// healthy, they are all tried anyway.
+type ServiceRegistry struct {
+	FailoverCooldown time.Duration // 0 for DefaultFailoverCooldown
+	HealthTimeout    time.Duration // Per health check, 0 for 5s
+	Client           *HTTPClient   // For health checks, nil for the shared one
+	Logger           *slog.Logger  // For file reloads, nil for slog.Default()
+
+	mu       sync.RWMutex
+	services map[string]*registeredService
+	done     chan bool
+	stopped  sync.Once
+}
+
+type registeredService struct {
+	config    ServiceConfig
+	source    string // File it was loaded from, if any
+	endpoints []*serviceEndpoint
+}
+
+type serviceEndpoint struct {
+	base      *url.URL
+	down      bool
+	downUntil time.Time // Zero if down until the next health check
+}
+
+// Can 'ep' be used now?
+func (ep *serviceEndpoint) usable(now time.Time) bool {
+	return !ep.down || (!ep.downUntil.IsZero() && now.After(ep.downUntil))
+}
+
+// Create an empty ServiceRegistry.
+func NewServiceRegistry() *ServiceRegistry {
+	return &ServiceRegistry{
+		services: map[string]*registeredService{},
+		done:     make(chan bool),
+	}
+}
+
+func normalizeServiceName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// Register (or replace) service 'name'.
+func (r *ServiceRegistry) SetService(name string, config ServiceConfig) error {
+	return r.setService(name, config, "")
+}
+
+func (r *ServiceRegistry) setService(name string, config ServiceConfig, source string) error {
+	name = normalizeServiceName(name)
+	if name == "" {
+		return fmt.Errorf("service name can not be empty")
+	}
+	if len(config.URLs) == 0 {
+		return fmt.Errorf("service '%s' has no URLs", name)
+	}
+	svc := &registeredService{config: config, source: source}
+	for _, raw := range config.URLs {
+		u, err := url.Parse(strings.TrimSpace(raw))
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("service '%s' has invalid URL '%s'", name, raw)
+		}
+		u.Path = strings.TrimRight(u.Path, "/")
+		u.RawPath = ""
+		svc.endpoints = append(svc.endpoints, &serviceEndpoint{base: u})
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Keep what we know about the health of endpoints we already had.
+	if old := r.services[name]; old != nil {
+		for _, ep := range svc.endpoints {
+			for _, oldEP := range old.endpoints {
+				if oldEP.base.String() == ep.base.String() {
+					ep.down, ep.downUntil = oldEP.down, oldEP.downUntil
+				}
+			}
+		}
+	}
+	r.services[name] = svc
+	return nil
+}
+
+// Unregister service 'name'.
+func (r *ServiceRegistry) RemoveService(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.services, normalizeServiceName(name))
+}
+
+// Returns the names of the registered services, sorted.
+func (r *ServiceRegistry) Services() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.services))
+	for name := range r.services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Register the services given by environment variables (see
+// ServiceEnvPrefix).  URLs are separated by commas.
+func (r *ServiceRegistry) LoadEnv() error {
+	configs := map[string]*ServiceConfig{}
+	for _, kv := range os.Environ() {
+		key, val, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(key, ServiceEnvPrefix) {
+			continue
+		}
+		key = strings.TrimPrefix(key, ServiceEnvPrefix)
+		var name string
+		var isURLs bool
+		if n, ok := strings.CutSuffix(key, "_URLS"); ok {
+			name, isURLs = n, true
+		} else if n, ok := strings.CutSuffix(key, "_HEALTH_PATH"); ok {
+			name = n
+		} else {
+			continue
+		}
+		name = strings.ReplaceAll(strings.ToLower(name), "_", "-")
+		if configs[name] == nil {
+			configs[name] = &ServiceConfig{}
+		}
+		if isURLs {
+			for _, u := range strings.Split(val, ",") {
+				if u = strings.TrimSpace(u); u != "" {
+					configs[name].URLs = append(configs[name].URLs, u)
+				}
+			}
+		} else {
+			configs[name].HealthPath = val
+		}
+	}
+	var errs []error
+	for name, config := range configs {
+		if err := r.SetService(name, *config); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Register the services in the registry file 'file' (see ServiceConfig),
+// which is YAML if its name ends in .yaml or .yml, and JSON otherwise.
+// Services that were loaded from the same file before but are no longer in
+// it are removed.  On error nothing is changed.
+func (r *ServiceRegistry) LoadFile(file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("unable to read service registry: %w", err)
+	}
+	var configs map[string]ServiceConfig
+	switch strings.ToLower(path.Ext(file)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &configs)
+	default:
+		err = json.Unmarshal(data, &configs)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to parse service registry %s: %w", file, err)
+	}
+
+	// Check it all first, so a bad file changes nothing.
+	check := NewServiceRegistry()
+	for name, config := range configs {
+		if err := check.SetService(name, config); err != nil {
+			return fmt.Errorf("invalid service registry %s: %w", file, err)
+		}
+	}
+	for name, config := range configs {
+		r.setService(name, config, file)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name, svc := range r.services {
+		if _, ok := check.services[name]; !ok && svc.source == file {
+			delete(r.services, name)
+		}
+	}
+	return nil
+}
+
+// Load the registry file 'file' (see LoadFile()) and reload it whenever it
+// changes, until Stop() is called.  'file' must be an absolute path.  If a
+// reload fails, an error is logged and the previous services stay
+// registered.
+func (r *ServiceRegistry) WatchFile(file string) error {
+	if !path.IsAbs(file) {
+		return fmt.Errorf("WatchFile: Must be the absolute path: %s", file)
+	}
+	if err := r.LoadFile(file); err != nil {
+		return err
+	}
+
+	// As with TLSWatcher, watch the directory in case it is a mounted
+	// ConfigMap, which changes by replacing a symlink.
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(path.Dir(file)); err != nil {
+		watcher.Close()
+		return err
+	}
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case <-watcher.Events:
+				if err := r.LoadFile(file); err != nil {
+					loggerOrDefault(r.Logger).Warn("Failed to reload service registry, keeping previous services",
+						"file", file, "error", err)
+				}
+			case err := <-watcher.Errors:
+				loggerOrDefault(r.Logger).Error("Service registry file watch failed", "file", file, "error", err)
+			case <-r.done:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop watching files and checking health.
+func (r *ServiceRegistry) Stop() {
+	r.stopped.Do(func() {
+		close(r.done)
+	})
+}
+
+// Returns the base URLs of service 'name' in the order they would be
+// tried now: healthy ones first, in order of preference.
+func (r *ServiceRegistry) Endpoints(name string) []string {
+	var urls []string
+	for _, ep := range r.candidates(normalizeServiceName(name)) {
+		urls = append(urls, ep.String())
+	}
+	return urls
+}
+
+// Base URLs of service 'name' to try, in order.  nil if it isn't
+// registered.
+func (r *ServiceRegistry) candidates(name string) []*url.URL {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	svc := r.services[name]
+	if svc == nil {
+		return nil
+	}
+	now := time.Now()
+	var healthy, unhealthy []*url.URL
+	for _, ep := range svc.endpoints {
+		if ep.usable(now) {
+			healthy = append(healthy, ep.base)
+		} else {
+			unhealthy = append(unhealthy, ep.base)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+// Returns the URL of 'elems' (joined with '/', as by url.JoinPath) on the
+// preferred endpoint of service 'name', e.g.
+//
+//  u, err := r.URL("cray-smd", "hsm/v2/State/Components", xname)
+func (r *ServiceRegistry) URL(name string, elems ...string) (string, error) {
+	candidates := r.candidates(normalizeServiceName(name))
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("unknown service '%s'", name)
+	}
+	if len(elems) == 0 {
+		return candidates[0].String(), nil
+	}
+	return candidates[0].JoinPath(elems...).String(), nil
+}
+
+// Mark the endpoint of 'name' with base URL 'base' healthy or not.
+// 'until' is when an unhealthy one may be tried again, zero for after the
+// next health check.
+func (r *ServiceRegistry) setHealth(name string, base *url.URL, healthy bool, until time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	svc := r.services[name]
+	if svc == nil {
+		return
+	}
+	for _, ep := range svc.endpoints {
+		if ep.base == base {
+			ep.down, ep.downUntil = !healthy, until
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////
+// Health checks
+////////////////////////////////////////////////////////////////////////////
+
+// Check the health of every endpoint of every service with a HealthPath
+// now, and then every 'interval' until Stop() is called.
+func (r *ServiceRegistry) StartHealthChecks(interval time.Duration) {
+	r.CheckHealth(context.Background())
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				r.CheckHealth(context.Background())
+			case <-r.done:
+				return
+			}
+		}
+	}()
+}
+
+// Check the health of every endpoint of every service with a HealthPath,
+// concurrently, and wait for the results.  An endpoint is healthy if GET
+// of its HealthPath returns a 2xx status.
+func (r *ServiceRegistry) CheckHealth(ctx context.Context) {
+	type check struct {
+		name string
+		base *url.URL
+		url  string
+	}
+	var checks []check
+	r.mu.RLock()
+	for name, svc := range r.services {
+		if svc.config.HealthPath == "" {
+			continue
+		}
+		for _, ep := range svc.endpoints {
+			checks = append(checks, check{name, ep.base,
+				ep.base.JoinPath(svc.config.HealthPath).String()})
+		}
+	}
+	r.mu.RUnlock()
+
+	timeout := r.HealthTimeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	client := r.Client
+	if client == nil {
+		client = GetSharedHTTPClient()
+	}
+	// Not HTTPRequest, which would retry, and not through the registry,
+	// which would send the check to whichever endpoint it liked.
+	httpClient := &http.Client{Transport: client.directTransport(false), Timeout: timeout}
+
+	var wg sync.WaitGroup
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c check) {
+			defer wg.Done()
+			healthy := false
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+			if err == nil {
+				var resp *http.Response
+				if resp, err = httpClient.Do(req); err == nil {
+					io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+					resp.Body.Close()
+					healthy = resp.StatusCode >= 200 && resp.StatusCode <= 299
+				}
+			}
+			r.setHealth(c.name, c.base, healthy, time.Time{})
+		}(c)
+	}
+	wg.Wait()
+}
+
+////////////////////////////////////////////////////////////////////////////
+// Failover
+////////////////////////////////////////////////////////////////////////////
+
+// Returns a RoundTripper that sends requests to registered services to
+// their endpoints (see ServiceRegistry), using 'next' for the actual
+// requests.  HTTPClient.SetServiceRegistry() does this for HTTPRequests.
+//
+// A request with a body is only resent to another endpoint if it has a
+// GetBody to make a fresh copy of the body, as HTTPRequests and those from
+// http.NewRequest() with a bytes or strings reader do; others only go to
+// the first endpoint tried.
+func (r *ServiceRegistry) RoundTripper(next http.RoundTripper) http.RoundTripper {
+	return &registryTransport{registry: r, next: next}
+}
+
+type registryTransport struct {
+	registry *ServiceRegistry
+	next     http.RoundTripper
+}
+
+func (t *registryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	name := ""
+	if req.URL.Port() == "" {
+		name = normalizeServiceName(req.URL.Hostname())
+	}
+	candidates := t.registry.candidates(name)
+	if len(candidates) == 0 {
+		return t.next.RoundTrip(req)
+	}
+
+	cooldown := t.registry.FailoverCooldown
+	if cooldown <= 0 {
+		cooldown = DefaultFailoverCooldown
+	}
+	var errs []error
+	for i, base := range candidates {
+		out := req.Clone(req.Context())
+		out.URL.Scheme = base.Scheme
+		out.URL.Host = base.Host
+		out.URL.Path = base.Path + req.URL.Path
+		out.URL.RawPath = ""
+		out.Host = ""
+		if i > 0 && req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				break
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				break
+			}
+			out.Body = body
+		}
+
+		resp, err := t.next.RoundTrip(out)
+		if err == nil {
+			return resp, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", base.Host, err))
+		if !isConnectError(err) || req.Context().Err() != nil {
+			break
+		}
+		t.registry.setHealth(name, base, false, time.Now().Add(cooldown))
+	}
+	if len(errs) == 1 {
+		return nil, errors.Unwrap(errs[0])
+	}
+	return nil, fmt.Errorf("all endpoints of service '%s' failed: %w", name, errors.Join(errs...))
+}
+
+func (t *registryTransport) CloseIdleConnections() {
+	if closer, ok := t.next.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// Is 'err' a failure to connect, as opposed to one during the exchange
+// (after which it is not safe to assume the request was not acted on)?
+func isConnectError(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
@@ -0,0 +1,304 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package base
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Address nothing is listening on.
+func refusedAddr(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Can't listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	return addr
+}
+
+func TestServiceRegistryURL(t *testing.T) {
+	r := NewServiceRegistry()
+	err := r.SetService("cray-smd", ServiceConfig{URLs: []string{"http://cray-smd/", "http://smd-2:27779/base"}})
+	if err != nil {
+		t.Fatalf("SetService() failed: %v", err)
+	}
+	u, err := r.URL("Cray-SMD", "hsm/v2/State/Components", "x0c0s0b0n0")
+	if err != nil || u != "http://cray-smd/hsm/v2/State/Components/x0c0s0b0n0" {
+		t.Errorf("Unexpected URL '%s', %v", u, err)
+	}
+	expect := []string{"http://cray-smd", "http://smd-2:27779/base"}
+	if eps := r.Endpoints("cray-smd"); !reflect.DeepEqual(eps, expect) {
+		t.Errorf("Expected endpoints %v, got %v", expect, eps)
+	}
+	if _, err := r.URL("cray-hbtd"); err == nil {
+		t.Errorf("Expected an error for an unknown service, got none")
+	}
+
+	bad := []ServiceConfig{{}, {URLs: []string{"cray-smd"}}, {URLs: []string{"ftp://cray-smd"}}}
+	for _, config := range bad {
+		if err := r.SetService("bad", config); err == nil {
+			t.Errorf("Expected an error for %+v, got none", config)
+		}
+	}
+	if err := r.SetService("", ServiceConfig{URLs: []string{"http://x"}}); err == nil {
+		t.Errorf("Expected an error for an empty name, got none")
+	}
+	r.RemoveService("cray-smd")
+	if len(r.Services()) != 0 {
+		t.Errorf("Expected no services after RemoveService(), got %v", r.Services())
+	}
+}
+
+func TestServiceRegistryLoadEnv(t *testing.T) {
+	t.Setenv(ServiceEnvPrefix+"CRAY_SMD_URLS", "http://cray-smd, http://smd-2")
+	t.Setenv(ServiceEnvPrefix+"CRAY_SMD_HEALTH_PATH", "/hsm/v2/service/ready")
+	t.Setenv(ServiceEnvPrefix+"CRAY_HBTD_URLS", "http://cray-hbtd")
+
+	r := NewServiceRegistry()
+	if err := r.LoadEnv(); err != nil {
+		t.Fatalf("LoadEnv() failed: %v", err)
+	}
+	if !reflect.DeepEqual(r.Services(), []string{"cray-hbtd", "cray-smd"}) {
+		t.Errorf("Unexpected services %v", r.Services())
+	}
+	if eps := r.Endpoints("cray-smd"); len(eps) != 2 || eps[1] != "http://smd-2" {
+		t.Errorf("Unexpected endpoints %v", eps)
+	}
+	if hp := r.services["cray-smd"].config.HealthPath; hp != "/hsm/v2/service/ready" {
+		t.Errorf("Unexpected health path '%s'", hp)
+	}
+}
+
+func TestServiceRegistryWatchFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "services.json")
+	write := func(content string) {
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatalf("Can't write %s: %v", file, err)
+		}
+	}
+	write(`{"cray-smd": {"URLs": ["http://cray-smd"]}, "cray-hbtd": {"URLs": ["http://cray-hbtd"]}}`)
+
+	r := NewServiceRegistry()
+	defer r.Stop()
+	r.SetService("cray-bss", ServiceConfig{URLs: []string{"http://cray-bss"}})
+	if err := r.WatchFile(file); err != nil {
+		t.Fatalf("WatchFile() failed: %v", err)
+	}
+	if len(r.Services()) != 3 {
+		t.Errorf("Expected 3 services, got %v", r.Services())
+	}
+
+	// Services gone from the file go, others stay.
+	write(`{"cray-smd": {"URLs": ["http://smd-2"]}}`)
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) && len(r.Services()) != 2 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !reflect.DeepEqual(r.Services(), []string{"cray-bss", "cray-smd"}) {
+		t.Errorf("Unexpected services after reload: %v", r.Services())
+	}
+	if u, _ := r.URL("cray-smd"); u != "http://smd-2" {
+		t.Errorf("Expected reloaded URL 'http://smd-2', got '%s'", u)
+	}
+
+	// A failed reload is logged.
+	var logged atomic.Bool
+	r.Logger = slog.New(slog.NewTextHandler(writerFunc(func(p []byte) (int, error) {
+		if strings.Contains(string(p), "keeping previous services") {
+			logged.Store(true)
+		}
+		return len(p), nil
+	}), nil))
+	write(`{"cray-smd": `)
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline) && !logged.Load(); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !logged.Load() {
+		t.Errorf("Failed reload not logged")
+	}
+
+	// A bad file changes nothing.
+	if err := r.LoadFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Errorf("Expected an error loading a missing file, got none")
+	}
+	bad := filepath.Join(t.TempDir(), "bad.json")
+	os.WriteFile(bad, []byte(`{"cray-smd": {"URLs": []}}`), 0644)
+	if err := r.LoadFile(bad); err == nil {
+		t.Errorf("Expected an error loading a bad file, got none")
+	}
+	if u, _ := r.URL("cray-smd"); u != "http://smd-2" {
+		t.Errorf("Bad file changed the registry: '%s'", u)
+	}
+	if err := r.WatchFile("relative.json"); err == nil {
+		t.Errorf("Expected an error watching a relative path, got none")
+	}
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+func TestServiceRegistryLoadYAML(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "services.yaml")
+	os.WriteFile(file, []byte(`# Services for this test
+cray-smd:
+  URLs:
+    - http://cray-smd
+    - http://cray-smd-2.services:27779
+  HealthPath: /hsm/v2/service/ready
+cray-hbtd:
+  URLs: [http://cray-hbtd]
+`), 0644)
+
+	r := NewServiceRegistry()
+	if err := r.LoadFile(file); err != nil {
+		t.Fatalf("LoadFile() failed: %v", err)
+	}
+	if eps := r.Endpoints("cray-smd"); !reflect.DeepEqual(eps,
+		[]string{"http://cray-smd", "http://cray-smd-2.services:27779"}) {
+		t.Errorf("Unexpected cray-smd endpoints: %v", eps)
+	}
+	if path := r.services["cray-smd"].config.HealthPath; path != "/hsm/v2/service/ready" {
+		t.Errorf("Unexpected HealthPath '%s'", path)
+	}
+	if !reflect.DeepEqual(r.Services(), []string{"cray-hbtd", "cray-smd"}) {
+		t.Errorf("Unexpected services: %v", r.Services())
+	}
+
+	bad := filepath.Join(dir, "bad.yml")
+	os.WriteFile(bad, []byte("cray-smd:\n  URLs: [http://cray-smd\n"), 0644)
+	if err := r.LoadFile(bad); err == nil {
+		t.Errorf("Expected an error loading bad YAML, got none")
+	}
+}
+
+func TestServiceRegistryFailover(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte(r.Host + " " + r.URL.Path + " " + string(body)))
+	}))
+	defer srv.Close()
+
+	down := "http://" + refusedAddr(t)
+	r := NewServiceRegistry()
+	r.SetService("cray-smd", ServiceConfig{URLs: []string{down, srv.URL + "/base"}})
+	c := NewHTTPClient()
+	c.SetServiceRegistry(r)
+
+	request := NewHTTPRequest("http://cray-smd/hsm/v2/State/Components")
+	request.Client = c
+	request.Method = http.MethodPost
+	request.Payload = []byte(`{"a":1}`)
+	body, err := request.DoHTTPAction()
+	if err != nil {
+		t.Fatalf("Request with failover failed: %v", err)
+	}
+	expect := strings.TrimPrefix(srv.URL, "http://") + ` /base/hsm/v2/State/Components {"a":1}`
+	if string(body) != expect {
+		t.Errorf("Expected '%s', got '%s'", expect, body)
+	}
+	// The refused endpoint is now tried last.
+	if eps := r.Endpoints("cray-smd"); eps[0] != srv.URL+"/base" {
+		t.Errorf("Expected the working endpoint first, got %v", eps)
+	}
+
+	// A Body is resent to the next endpoint within the same attempt, not
+	// only on a retry (which would wait past the deadline).
+	r.SetService("cray-fas", ServiceConfig{URLs: []string{down, srv.URL}})
+	request = NewHTTPRequest("http://cray-fas/fas/v1/actions")
+	request.Client = c
+	request.Method = http.MethodPost
+	request.Body = NewBytesBody([]byte(`{"b":2}`))
+	request.OverallTimeout = 500 * time.Millisecond
+	body, err = request.DoHTTPAction()
+	expect = strings.TrimPrefix(srv.URL, "http://") + ` /fas/v1/actions {"b":2}`
+	if err != nil || string(body) != expect {
+		t.Errorf("Expected '%s' from failover with a body, got '%s', %v", expect, body, err)
+	}
+
+	// One whose body can't be rewound only goes to the first endpoint.
+	r.SetService("cray-bss", ServiceConfig{URLs: []string{down, srv.URL}})
+	req, _ := http.NewRequest(http.MethodPost, "http://cray-bss/", io.NopCloser(strings.NewReader("{}")))
+	if _, err := r.RoundTripper(http.DefaultTransport).RoundTrip(req); !isConnectError(err) {
+		t.Errorf("Expected only the refused endpoint tried, got: %v", err)
+	}
+
+	// Hosts that aren't services are left alone.
+	request = NewHTTPRequest(srv.URL + "/direct")
+	request.Client = c
+	if body, err := request.DoHTTPAction(); err != nil || !strings.HasSuffix(string(body), " /direct ") {
+		t.Errorf("Unexpected direct response '%s', %v", body, err)
+	}
+
+	// With no endpoint that works, all the errors are reported.
+	r.SetService("cray-hbtd", ServiceConfig{URLs: []string{down, "http://" + refusedAddr(t)}})
+	req, _ = http.NewRequest(http.MethodGet, "http://cray-hbtd/", nil)
+	_, err = r.RoundTripper(http.DefaultTransport).RoundTrip(req)
+	if err == nil || !strings.Contains(err.Error(), "all endpoints of service 'cray-hbtd' failed") {
+		t.Errorf("Expected an error naming the service, got: %v", err)
+	}
+}
+
+func TestServiceRegistryHealthChecks(t *testing.T) {
+	sick := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer sick.Close()
+	well := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ready" {
+			t.Errorf("Expected health check of /ready, got %s", r.URL.Path)
+		}
+	}))
+	defer well.Close()
+
+	r := NewServiceRegistry()
+	r.SetService("svc", ServiceConfig{URLs: []string{sick.URL, well.URL}, HealthPath: "/ready"})
+	r.CheckHealth(context.Background())
+	if eps := r.Endpoints("svc"); !reflect.DeepEqual(eps, []string{well.URL, sick.URL}) {
+		t.Errorf("Expected the healthy endpoint first, got %v", eps)
+	}
+
+	// Health is kept when the service is set again with the same URLs.
+	r.SetService("svc", ServiceConfig{URLs: []string{sick.URL, well.URL}, HealthPath: "/ready"})
+	if u, _ := r.URL("svc"); u != well.URL {
+		t.Errorf("Expected the healthy endpoint after SetService(), got %s", u)
+	}
+	r.StartHealthChecks(time.Hour)
+	r.Stop()
+}
@@ -0,0 +1,156 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package base
+
+import (
+	"strings"
+	"sync"
+)
+
+//
+// Translation of Redfish status into HMSState and HMSFlag
+//
+
+// The status of a component as reported by Redfish: its PowerState, if it
+// has one, and its Status.State and Status.Health.  Any may be empty.
+type RedfishStatus struct {
+	PowerState string
+	State      string
+	Health     string
+}
+
+// Adjusts the result of a translation for a particular vendor's
+// implementation, given the original Redfish status.
+type RedfishOverride func(rf RedfishStatus, state HMSState, flag HMSFlag) (HMSState, HMSFlag)
+
+// Translates Redfish status into HMSState and HMSFlag, so all services
+// agree on the state of a component.  Lookups in the tables are
+// case-insensitive; keys must be lower case.
+//
+// Translation goes as follows:
+//
+//   - If Status.State is in StatusStates (by default only Absent, for
+//     StateEmpty), that is the HMSState and the flag is FlagOK, since the
+//     health of a missing component means nothing.
+//   - Otherwise the PowerState is looked up in PowerStates.  Transitional
+//     power states count as the state being left, i.e. PoweringOn is still
+//     Off, until Redfish reports the change complete.  Components with no
+//     (or an unrecognized) PowerState are StatePopulated.
+//   - Status.Health is looked up in Healths, with Critical being FlagAlert.
+//     A missing Health is FlagOK and an unrecognized one FlagUnknown.
+//
+// Vendor overrides are then applied, in the order they were added, to
+// adjust the result for BMCs that don't follow the spec.
+type RedfishTranslator struct {
+	PowerStates  map[string]HMSState
+	StatusStates map[string]HMSState
+	Healths      map[string]HMSFlag
+
+	mu        sync.RWMutex
+	overrides map[string][]RedfishOverride
+}
+
+// Create a RedfishTranslator with the default tables.  The tables may be
+// modified before use.
+func NewRedfishTranslator() *RedfishTranslator {
+	return &RedfishTranslator{
+		PowerStates: map[string]HMSState{
+			"on":          StateOn,
+			"off":         StateOff,
+			"poweringon":  StateOff,
+			"poweringoff": StateOn,
+			"paused":      StateOn,
+		},
+		StatusStates: map[string]HMSState{
+			"absent": StateEmpty,
+		},
+		Healths: map[string]HMSFlag{
+			"ok":       FlagOK,
+			"warning":  FlagWarning,
+			"critical": FlagAlert,
+		},
+		overrides: map[string][]RedfishOverride{},
+	}
+}
+
+// Add an override for components from 'vendor', which is matched
+// case-insensitively against the vendor passed to Translate() (usually the
+// Redfish Manufacturer).
+func (t *RedfishTranslator) AddVendorOverride(vendor string, fn RedfishOverride) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := normalizeVendor(vendor)
+	t.overrides[key] = append(t.overrides[key], fn)
+}
+
+// Translate the Redfish status of a component from 'vendor' (may be empty)
+// into its HMSState and HMSFlag.
+func (t *RedfishTranslator) Translate(vendor string, rf RedfishStatus) (HMSState, HMSFlag) {
+	state, flag := t.translate(rf)
+
+	t.mu.RLock()
+	overrides := t.overrides[normalizeVendor(vendor)]
+	t.mu.RUnlock()
+	for _, fn := range overrides {
+		state, flag = fn(rf, state, flag)
+	}
+	return state, flag
+}
+
+func (t *RedfishTranslator) translate(rf RedfishStatus) (HMSState, HMSFlag) {
+	if state, ok := t.StatusStates[strings.ToLower(rf.State)]; ok {
+		return state, FlagOK
+	}
+
+	state, ok := t.PowerStates[strings.ToLower(rf.PowerState)]
+	if !ok {
+		state = StatePopulated
+	}
+
+	flag := FlagOK
+	if rf.Health != "" {
+		if flag, ok = t.Healths[strings.ToLower(rf.Health)]; !ok {
+			flag = FlagUnknown
+		}
+	}
+	return state, flag
+}
+
+func normalizeVendor(vendor string) string {
+	return strings.ToLower(strings.TrimSpace(vendor))
+}
+
+// Used by TranslateRedfishStatus() and AddRedfishVendorOverride().
+var defaultRedfishTranslator = NewRedfishTranslator()
+
+// Translate Redfish status into HMSState and HMSFlag with the default
+// translator.  See RedfishTranslator.
+func TranslateRedfishStatus(vendor string, rf RedfishStatus) (HMSState, HMSFlag) {
+	return defaultRedfishTranslator.Translate(vendor, rf)
+}
+
+// Add a vendor override to the default translator.  Best done in an init()
+// function, so the override applies from the start.
+func AddRedfishVendorOverride(vendor string, fn RedfishOverride) {
+	defaultRedfishTranslator.AddVendorOverride(vendor, fn)
+}
@@ -0,0 +1,83 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package base
+
+import (
+	"testing"
+)
+
+func TestTranslateRedfishStatus(t *testing.T) {
+	tests := []struct {
+		rf    RedfishStatus
+		state HMSState
+		flag  HMSFlag
+	}{
+		{RedfishStatus{"On", "Enabled", "OK"}, StateOn, FlagOK},
+		{RedfishStatus{"off", "", ""}, StateOff, FlagOK},
+		{RedfishStatus{"PoweringOn", "Starting", "OK"}, StateOff, FlagOK},
+		{RedfishStatus{"PoweringOff", "Enabled", "Warning"}, StateOn, FlagWarning},
+		{RedfishStatus{"On", "Enabled", "Critical"}, StateOn, FlagAlert},
+		{RedfishStatus{"On", "Absent", "Critical"}, StateEmpty, FlagOK},
+		{RedfishStatus{"", "Enabled", "OK"}, StatePopulated, FlagOK},
+		{RedfishStatus{"", "Disabled", "Warning"}, StatePopulated, FlagWarning},
+		{RedfishStatus{"Sideways", "Enabled", "Fabulous"}, StatePopulated, FlagUnknown},
+	}
+	for _, tt := range tests {
+		state, flag := TranslateRedfishStatus("", tt.rf)
+		if state != tt.state || flag != tt.flag {
+			t.Errorf("%+v: expected %s/%s, got %s/%s", tt.rf, tt.state, tt.flag, state, flag)
+		}
+	}
+}
+
+func TestRedfishTranslatorOverrides(t *testing.T) {
+	tr := NewRedfishTranslator()
+	// A vendor whose enclosures report Disabled when they are powered off.
+	tr.AddVendorOverride("Contoso", func(rf RedfishStatus, state HMSState, flag HMSFlag) (HMSState, HMSFlag) {
+		if rf.PowerState == "" && rf.State == "Disabled" {
+			return StateOff, flag
+		}
+		return state, flag
+	})
+	tr.AddVendorOverride("contoso ", func(rf RedfishStatus, state HMSState, flag HMSFlag) (HMSState, HMSFlag) {
+		if flag == FlagUnknown {
+			return state, FlagWarning
+		}
+		return state, flag
+	})
+
+	rf := RedfishStatus{State: "Disabled", Health: "Degraded"}
+	if state, flag := tr.Translate("CONTOSO", rf); state != StateOff || flag != FlagWarning {
+		t.Errorf("Expected overrides to give Off/Warning, got %s/%s", state, flag)
+	}
+	if state, flag := tr.Translate("Fabrikam", rf); state != StatePopulated || flag != FlagUnknown {
+		t.Errorf("Expected no override for other vendor, got %s/%s", state, flag)
+	}
+
+	// Tables can be adjusted too.
+	tr.StatusStates["standbyoffline"] = StateStandby
+	rf = RedfishStatus{PowerState: "On", State: "StandbyOffline"}
+	if state, _ := tr.Translate("", rf); state != StateStandby {
+		t.Errorf("Expected table change to give Standby, got %s", state)
+	}
+}
@@ -0,0 +1,102 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package base
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Returned (wrapped) when an attempt at a request took longer than the
+// HTTPRequest's AttemptTimeout (or Timeout) and it was not retried, or was
+// the last attempt.
+var ErrAttemptTimeout = errors.New("HTTP request attempt timed out")
+
+// Returned (wrapped) when a request, retries and all, took longer than the
+// HTTPRequest's OverallTimeout.
+var ErrRequestDeadline = errors.New("HTTP request deadline exceeded")
+
+// Limit on each attempt at request, 0 for none.
+func (request *HTTPRequest) attemptTimeout() time.Duration {
+	if request.AttemptTimeout != 0 {
+		return request.AttemptTimeout
+	}
+	return request.Timeout
+}
+
+// Gives each attempt (each RoundTrip) its own deadline, which also covers
+// reading the response body.
+type attemptTransport struct {
+	next    http.RoundTripper
+	timeout time.Duration
+}
+
+func (t *attemptTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeoutCause(req.Context(), t.timeout, ErrAttemptTimeout)
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		if context.Cause(ctx) == ErrAttemptTimeout {
+			err = fmt.Errorf("%w after %v: %w", ErrAttemptTimeout, t.timeout, err)
+		}
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, ctx: ctx, cancel: cancel, limit: t.timeout}
+	return resp, nil
+}
+
+func (t *attemptTransport) CloseIdleConnections() {
+	if closer, ok := t.next.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// Response body whose context is cancelled once it is closed, and whose
+// read errors say which limit was hit if it was the context's deadline.
+type cancelBody struct {
+	io.ReadCloser
+	ctx    context.Context
+	cancel context.CancelFunc
+	limit  time.Duration
+}
+
+func (b *cancelBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF {
+		switch cause := context.Cause(b.ctx); cause {
+		case ErrAttemptTimeout, ErrRequestDeadline:
+			err = fmt.Errorf("%w after %v: %w", cause, b.limit, err)
+		}
+	}
+	return n, err
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
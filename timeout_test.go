@@ -0,0 +1,125 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package base
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAttemptTimeoutRetried(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			<-r.Context().Done()
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	request := NewHTTPRequest(srv.URL)
+	request.AttemptTimeout = 50 * time.Millisecond
+	body, err := request.DoHTTPAction()
+	if err != nil {
+		t.Fatalf("Expected the second attempt to succeed, got: %v", err)
+	}
+	if string(body) != "ok" || attempts != 2 {
+		t.Errorf("Expected 'ok' after 2 attempts, got '%s' after %d", body, attempts)
+	}
+}
+
+func TestOverallTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	// Without an overall limit this would take 5 attempts and 15s of
+	// backoff.
+	request := NewHTTPRequest(srv.URL)
+	request.AttemptTimeout = 50 * time.Millisecond
+	request.OverallTimeout = 300 * time.Millisecond
+	start := time.Now()
+	_, err := request.DoHTTPAction()
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected to give up after about 300ms, took %v", elapsed)
+	}
+	if !errors.Is(err, ErrRequestDeadline) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected ErrRequestDeadline, got: %v", err)
+	}
+	if errors.Is(err, ErrAttemptTimeout) {
+		t.Errorf("Did not expect ErrAttemptTimeout, got: %v", err)
+	}
+
+	// The caller's own deadline is not mistaken for ours.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	request.Context = ctx
+	request.AttemptTimeout = 0
+	request.Timeout = 0
+	_, err = request.DoHTTPAction()
+	if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrRequestDeadline) {
+		t.Errorf("Expected only context.DeadlineExceeded, got: %v", err)
+	}
+}
+
+func TestTimeoutReadingBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("start"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		setup  func(r *HTTPRequest)
+		expect error
+	}{
+		{func(r *HTTPRequest) { r.Timeout = 100 * time.Millisecond }, ErrAttemptTimeout},
+		{func(r *HTTPRequest) { r.AttemptTimeout = 100 * time.Millisecond }, ErrAttemptTimeout},
+		{func(r *HTTPRequest) {
+			r.Timeout = 0
+			r.OverallTimeout = 100 * time.Millisecond
+		}, ErrRequestDeadline},
+	}
+	for i, tt := range tests {
+		request := NewHTTPRequest(srv.URL)
+		tt.setup(request)
+		body, err := request.DoHTTPActionStream()
+		if err != nil {
+			t.Fatalf("Test %d: DoHTTPActionStream() failed: %v", i, err)
+		}
+		_, err = io.ReadAll(body)
+		body.Close()
+		if !errors.Is(err, tt.expect) {
+			t.Errorf("Test %d: expected %v, got: %v", i, tt.expect, err)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package base
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Locations of PEM-encoded TLS files, typically mounted into the pod from
+// Kubernetes secrets.  Any of them may be left empty:
+//
+//   - CAFile: CA bundle to trust.  If empty, the system roots are used
+//     by clients and client certificates are not verified by servers.
+//   - CertFile/KeyFile: Certificate (chain) and private key to present,
+//     i.e. the client certificate for mutual TLS on the client side or the
+//     server certificate on the server side.  Both or neither must be set.
+type TLSFiles struct {
+	CAFile   string
+	CertFile string
+	KeyFile  string
+}
+
+// The loaded contents of a set of TLSFiles.
+type tlsCredentials struct {
+	caPool *x509.CertPool   // nil if no CAFile
+	cert   *tls.Certificate // nil if no CertFile/KeyFile
+}
+
+func (files TLSFiles) load() (*tlsCredentials, error) {
+	creds := new(tlsCredentials)
+
+	if files.CAFile != "" {
+		pem, err := os.ReadFile(files.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA bundle: %w", err)
+		}
+		creds.caPool = x509.NewCertPool()
+		if !creds.caPool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no valid certificates found in CA bundle %s",
+				files.CAFile)
+		}
+	}
+
+	if (files.CertFile == "") != (files.KeyFile == "") {
+		return nil, fmt.Errorf("certificate and key files must both be set, or neither")
+	}
+	if files.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(files.CertFile, files.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load certificate/key pair: %w", err)
+		}
+		creds.cert = &cert
+	}
+	return creds, nil
+}
+
+func (creds *tlsCredentials) clientConfig() *tls.Config {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		RootCAs:    creds.caPool,
+	}
+	if creds.cert != nil {
+		cfg.Certificates = []tls.Certificate{*creds.cert}
+	}
+	return cfg
+}
+
+func (creds *tlsCredentials) serverConfig(clientAuth tls.ClientAuthType) *tls.Config {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientCAs:  creds.caPool,
+		ClientAuth: clientAuth,
+	}
+	if creds.cert != nil {
+		cfg.Certificates = []tls.Certificate{*creds.cert}
+	}
+	return cfg
+}
+
+// Load 'files' and return a client-side TLS configuration for them, e.g.
+// to pass to HTTPClient.SetTLSConfig().  The files are read only once; use
+// WatchTLSFiles() to pick up rotated certificates.
+func LoadClientTLSConfig(files TLSFiles) (*tls.Config, error) {
+	creds, err := files.load()
+	if err != nil {
+		return nil, err
+	}
+	return creds.clientConfig(), nil
+}
+
+// Load 'files' and return a server-side TLS configuration for them, e.g.
+// for http.Server.TLSConfig.  CertFile/KeyFile are the server's certificate
+// and CAFile, if set, is used to verify client certificates according to
+// 'clientAuth' (tls.RequireAndVerifyClientCert for mutual TLS).  The files
+// are read only once; use WatchTLSFiles() to pick up rotated certificates.
+func LoadServerTLSConfig(files TLSFiles, clientAuth tls.ClientAuthType) (*tls.Config, error) {
+	creds, err := files.load()
+	if err != nil {
+		return nil, err
+	}
+	return creds.serverConfig(clientAuth), nil
+}
+
+// TLSWatcher keeps a set of TLSFiles loaded, reloading them whenever they
+// change on disk (e.g. when cert-manager or vault rotates a Kubernetes
+// secret), and pushes the new settings to the HTTPClients and servers that
+// use it, so no restart is needed.
+//
+//  w, err := base.WatchTLSFiles(base.TLSFiles{
+//      CAFile:   "/etc/tls/ca.crt",
+//      CertFile: "/etc/tls/tls.crt",
+//      KeyFile:  "/etc/tls/tls.key",
+//  })
+//  if err != nil {
+//      ...
+//  }
+//  defer w.Stop()
+//
+//  // Outgoing requests present tls.crt and trust ca.crt.
+//  w.AddClient(base.GetSharedHTTPClient())
+//
+//  // Incoming requests must present a client cert signed by ca.crt.
+//  srv := &http.Server{
+//      Addr:      ":8443",
+//      TLSConfig: w.ServerTLSConfig(tls.RequireAndVerifyClientCert),
+//  }
+//  srv.ListenAndServeTLS("", "")
+//
+// If a reload fails, e.g. because only one of a certificate and its key has
+// been updated so far, an error is logged and the previous settings stay in
+// effect until the next change.
+type TLSWatcher struct {
+	files   TLSFiles
+	mu      sync.RWMutex
+	creds   *tlsCredentials
+	clients []*HTTPClient
+	watcher *fsnotify.Watcher
+	done    chan bool
+	stopped sync.Once
+}
+
+// Load 'files' and start watching them for changes.  All paths that are set
+// must be absolute.  Stop() should be called when it is no longer needed.
+func WatchTLSFiles(files TLSFiles) (*TLSWatcher, error) {
+	// Mounted secrets are symlinks which will not trigger change events,
+	// so, like watchConfig(), watch the directories they are in instead.
+	dirs := map[string]bool{}
+	for _, file := range []string{files.CAFile, files.CertFile, files.KeyFile} {
+		if file == "" {
+			continue
+		}
+		if !path.IsAbs(file) {
+			return nil, fmt.Errorf("WatchTLSFiles: Must be the absolute path: %s", file)
+		}
+		dirs[path.Dir(file)] = true
+	}
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("WatchTLSFiles: No TLS files to watch")
+	}
+
+	creds, err := files.load()
+	if err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	for dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return nil, err
+		}
+	}
+
+	w := &TLSWatcher{
+		files:   files,
+		creds:   creds,
+		watcher: watcher,
+		done:    make(chan bool),
+	}
+	go w.watch()
+	return w, nil
+}
+
+func (w *TLSWatcher) watch() {
+	defer w.watcher.Close()
+	for {
+		select {
+		// watch for events
+		case <-w.watcher.Events:
+			w.Reload()
+		// watch for errors
+		case err := <-w.watcher.Errors:
+			log.Printf("ERROR: TLSWatcher: %s\n", err)
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// Reload the files now.  This is normally done automatically when they
+// change, but can also be called directly.  On error the previous settings
+// stay in effect.
+func (w *TLSWatcher) Reload() error {
+	creds, err := w.files.load()
+	if err != nil {
+		log.Printf("Warning: TLSWatcher: Failed to reload TLS files, keeping previous ones: %s\n", err)
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.creds = creds
+	for _, c := range w.clients {
+		c.SetTLSConfig(creds.clientConfig())
+	}
+	return nil
+}
+
+// Stop watching for changes.  The current settings stay in effect.
+func (w *TLSWatcher) Stop() {
+	w.stopped.Do(func() {
+		close(w.done)
+	})
+}
+
+// Returns a client-side TLS configuration for the currently loaded files.
+// It is a snapshot and will not change when the files do; use AddClient()
+// for that.
+func (w *TLSWatcher) ClientTLSConfig() *tls.Config {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.creds.clientConfig()
+}
+
+// Apply the currently loaded files to 'c' (see HTTPClient.SetTLSConfig()),
+// and again every time they are reloaded.
+func (w *TLSWatcher) AddClient(c *HTTPClient) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.clients = append(w.clients, c)
+	c.SetTLSConfig(w.creds.clientConfig())
+}
+
+// Returns a server-side TLS configuration (see LoadServerTLSConfig()) that
+// always uses the most recently loaded files, so new connections pick up a
+// rotated server certificate or CA bundle as soon as it is reloaded.
+func (w *TLSWatcher) ServerTLSConfig(clientAuth tls.ClientAuthType) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			w.mu.RLock()
+			defer w.mu.RUnlock()
+			return w.creds.serverConfig(clientAuth), nil
+		},
+	}
+}
@@ -0,0 +1,241 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package base
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// Test Helper Functions
+///////////////////////////////////////////////////////////////////////////////
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// Create a certificate signed by 'ca', or a self-signed CA if 'ca' is nil.
+func newTestCert(t *testing.T, ca *testCert, name string) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Can't generate key: %v", err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	parent, signer := tmpl, key
+	if ca == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		parent, signer = ca.cert, ca.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatalf("Can't create certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+// Atomically (re)write a file, like a Kubernetes secret update would.
+func writeTestFile(t *testing.T, file string, data []byte) {
+	t.Helper()
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		t.Fatalf("Can't write %s: %v", tmp, err)
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		t.Fatalf("Can't rename %s: %v", tmp, err)
+	}
+}
+
+// Write CA bundle, cert and key for 'cert' into 'dir'.
+func writeTestTLSFiles(t *testing.T, dir string, ca, cert *testCert) TLSFiles {
+	t.Helper()
+	files := TLSFiles{
+		CAFile:   filepath.Join(dir, "ca.crt"),
+		CertFile: filepath.Join(dir, "tls.crt"),
+		KeyFile:  filepath.Join(dir, "tls.key"),
+	}
+	writeTestFile(t, files.CAFile, ca.certPEM)
+	writeTestFile(t, files.KeyFile, cert.keyPEM)
+	writeTestFile(t, files.CertFile, cert.certPEM)
+	return files
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// Unit Tests
+///////////////////////////////////////////////////////////////////////////////
+
+func TestLoadTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, nil, "ca")
+	files := writeTestTLSFiles(t, dir, ca, newTestCert(t, ca, "svc"))
+
+	cfg, err := LoadClientTLSConfig(files)
+	if err != nil {
+		t.Fatalf("LoadClientTLSConfig() failed: %v", err)
+	}
+	if cfg.RootCAs == nil || len(cfg.Certificates) != 1 {
+		t.Errorf("Client config missing CA pool or certificate: %+v", cfg)
+	}
+	cfg, err = LoadServerTLSConfig(files, tls.RequireAndVerifyClientCert)
+	if err != nil {
+		t.Fatalf("LoadServerTLSConfig() failed: %v", err)
+	}
+	if cfg.ClientCAs == nil || len(cfg.Certificates) != 1 ||
+		cfg.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Errorf("Server config missing CA pool, certificate or client auth: %+v", cfg)
+	}
+
+	// Only a CA is fine.
+	if _, err := LoadClientTLSConfig(TLSFiles{CAFile: files.CAFile}); err != nil {
+		t.Errorf("LoadClientTLSConfig() with only a CA failed: %v", err)
+	}
+
+	badCA := filepath.Join(dir, "bad.crt")
+	writeTestFile(t, badCA, []byte("not a certificate"))
+	bad := []TLSFiles{
+		{CAFile: badCA},
+		{CAFile: filepath.Join(dir, "missing.crt")},
+		{CertFile: files.CertFile},
+		{KeyFile: files.KeyFile},
+		{CertFile: files.KeyFile, KeyFile: files.CertFile},
+	}
+	for i, files := range bad {
+		if _, err := LoadClientTLSConfig(files); err == nil {
+			t.Errorf("Test %d: expected error loading %+v", i, files)
+		}
+	}
+
+	if _, err := WatchTLSFiles(TLSFiles{CAFile: "ca.crt"}); err == nil {
+		t.Errorf("Expected error watching relative path")
+	}
+	if _, err := WatchTLSFiles(TLSFiles{}); err == nil {
+		t.Errorf("Expected error watching no files")
+	}
+}
+
+func TestTLSWatcherRotation(t *testing.T) {
+	ca1 := newTestCert(t, nil, "ca1")
+	ca2 := newTestCert(t, nil, "ca2")
+
+	srvDir, cliDir := t.TempDir(), t.TempDir()
+	srvFiles := writeTestTLSFiles(t, srvDir, ca1, newTestCert(t, ca1, "server"))
+	cliFiles := writeTestTLSFiles(t, cliDir, ca1, newTestCert(t, ca1, "client"))
+
+	srvWatch, err := WatchTLSFiles(srvFiles)
+	if err != nil {
+		t.Fatalf("WatchTLSFiles() failed: %v", err)
+	}
+	defer srvWatch.Stop()
+	cliWatch, err := WatchTLSFiles(cliFiles)
+	if err != nil {
+		t.Fatalf("WatchTLSFiles() failed: %v", err)
+	}
+	defer cliWatch.Stop()
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	srv.TLS = srvWatch.ServerTLSConfig(tls.RequireAndVerifyClientCert)
+	srv.StartTLS()
+	defer srv.Close()
+
+	client := NewHTTPClient()
+	cliWatch.AddClient(client)
+
+	request := NewHTTPRequest(srv.URL)
+	request.Client = client
+	payload, err := request.DoHTTPAction()
+	if err != nil {
+		t.Fatalf("Mutual TLS request failed: %v", err)
+	}
+	if string(payload) != "client" {
+		t.Errorf("Server saw wrong client cert, expected 'client', got '%s'", payload)
+	}
+
+	// Rotate the server onto a new CA.  The client doesn't trust it yet.
+	writeTestTLSFiles(t, srvDir, ca2, newTestCert(t, ca2, "server2"))
+	waitForTLS(t, request, false)
+
+	// Now rotate the client too, and it should work again.
+	writeTestTLSFiles(t, cliDir, ca2, newTestCert(t, ca2, "client2"))
+	waitForTLS(t, request, true)
+	payload, _ = request.DoHTTPAction()
+	if string(payload) != "client2" {
+		t.Errorf("Server saw wrong client cert, expected 'client2', got '%s'", payload)
+	}
+}
+
+// Wait for the result of 'request' to become 'ok' as files are reloaded.
+// Server-side changes only apply to new connections, so don't reuse any.
+func waitForTLS(t *testing.T, request *HTTPRequest, ok bool) {
+	t.Helper()
+	var err error
+	for i := 0; i < 50; i++ {
+		request.Client.CloseIdleConnections()
+		if _, err = request.DoHTTPAction(); (err == nil) == ok {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for request success to be %t, last error: %v", ok, err)
+}
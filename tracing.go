@@ -0,0 +1,398 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package base
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////
+//
+// W3C Trace Context (https://www.w3.org/TR/trace-context/)
+//
+// Trace contexts arrive in the traceparent/tracestate headers and are put
+// into the request context by TraceMiddleware.  HTTPRequests made with that
+// context (or one derived from it) pass them on to the next service, so a
+// request can be followed through every HMS service it touches.
+//
+// Each handled request and each HTTPRequest is recorded as a Span, which is
+// handed to the SpanExporter set with SetSpanExporter() once finished.  By
+// default they are simply dropped; plug in an exporter for whatever tracing
+// backend is in use.
+//
+////////////////////////////////////////////////////////////////////////////
+
+const TraceparentHeader = "traceparent"
+const TracestateHeader = "tracestate"
+
+// Only version of traceparent we generate.
+const traceparentVersion = "00"
+
+// tracestate values longer than this may be dropped, per the spec.
+const maxTracestateLen = 512
+
+// Set in TraceContext.Flags if the trace is being recorded.
+const TraceFlagSampled byte = 0x01
+
+// The position of a request in a trace, as carried by the traceparent and
+// tracestate headers.  SpanID is the ID of the caller's span, i.e. the
+// parent of any span started from it.
+type TraceContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Flags   byte
+	State   string // tracestate, passed on unchanged
+}
+
+// Parse a traceparent header value.  Values with a future version are
+// accepted as long as they start with the fields we know about.
+func ParseTraceparent(traceparent string) (TraceContext, error) {
+	var tc TraceContext
+
+	// version "-" trace-id "-" parent-id "-" trace-flags
+	if len(traceparent) < 55 || traceparent[2] != '-' || traceparent[35] != '-' ||
+		traceparent[52] != '-' {
+		return tc, fmt.Errorf("malformed traceparent '%s'", traceparent)
+	}
+	version := traceparent[0:2]
+	if !isLowerHex(version) || version == "ff" {
+		return tc, fmt.Errorf("invalid traceparent version '%s'", version)
+	}
+	if len(traceparent) > 55 && (version == traceparentVersion || traceparent[55] != '-') {
+		return tc, fmt.Errorf("malformed traceparent '%s'", traceparent)
+	}
+	fields := []string{traceparent[3:35], traceparent[36:52], traceparent[53:55]}
+	for _, field := range fields {
+		if !isLowerHex(field) {
+			return tc, fmt.Errorf("malformed traceparent '%s'", traceparent)
+		}
+	}
+	hex.Decode(tc.TraceID[:], []byte(fields[0]))
+	hex.Decode(tc.SpanID[:], []byte(fields[1]))
+	flags, _ := strconv.ParseUint(fields[2], 16, 8)
+	tc.Flags = byte(flags)
+
+	if !tc.IsValid() {
+		return tc, fmt.Errorf("traceparent has all-zero trace or parent ID '%s'", traceparent)
+	}
+	return tc, nil
+}
+
+func isLowerHex(s string) bool {
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns false if either ID is all zeros, which the spec does not allow.
+func (tc TraceContext) IsValid() bool {
+	return tc.TraceID != [16]byte{} && tc.SpanID != [8]byte{}
+}
+
+// Returns true if the sampled flag is set.
+func (tc TraceContext) IsSampled() bool {
+	return tc.Flags&TraceFlagSampled != 0
+}
+
+// Trace ID as 32 lowercase hex digits.
+func (tc TraceContext) TraceIDString() string {
+	return hex.EncodeToString(tc.TraceID[:])
+}
+
+// Span ID as 16 lowercase hex digits.
+func (tc TraceContext) SpanIDString() string {
+	return hex.EncodeToString(tc.SpanID[:])
+}
+
+// Render tc as a traceparent header value.
+func (tc TraceContext) Traceparent() string {
+	return fmt.Sprintf("%s-%s-%s-%02x",
+		traceparentVersion, tc.TraceIDString(), tc.SpanIDString(), tc.Flags)
+}
+
+// Extract the trace context from the traceparent and tracestate headers in
+// 'h'.  Returns false if there is no valid traceparent, in which case
+// tracestate is ignored too, as the spec requires.
+func ExtractTraceContext(h http.Header) (TraceContext, bool) {
+	tc, err := ParseTraceparent(h.Get(TraceparentHeader))
+	if err != nil {
+		return TraceContext{}, false
+	}
+	// Multiple tracestate headers are one comma-separated list.
+	state := ""
+	for i, val := range h.Values(TracestateHeader) {
+		if i > 0 {
+			state += ","
+		}
+		state += val
+	}
+	if len(state) <= maxTracestateLen {
+		tc.State = state
+	}
+	return tc, true
+}
+
+// Set the traceparent and tracestate headers in 'h' from 'tc'.
+func InjectTraceContext(h http.Header, tc TraceContext) {
+	h.Set(TraceparentHeader, tc.Traceparent())
+	if tc.State != "" {
+		h.Set(TracestateHeader, tc.State)
+	} else {
+		h.Del(TracestateHeader)
+	}
+}
+
+const traceContextKey = contextKey("traceContext")
+const spanContextKey = contextKey("span")
+
+// Returns a copy of 'ctx' carrying 'tc'.  Spans started from it will be
+// children of tc.SpanID.
+func ContextWithTraceContext(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey, tc)
+}
+
+// Returns the trace context carried by 'ctx', if any.
+func GetTraceContext(ctx context.Context) (TraceContext, bool) {
+	if ctx == nil {
+		return TraceContext{}, false
+	}
+	tc, ok := ctx.Value(traceContextKey).(TraceContext)
+	return tc, ok
+}
+
+// Returns the Span most recently started from 'ctx', or nil if there is none.
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanContextKey).(*Span)
+	return span
+}
+
+////////////////////////////////////////////////////////////////////////////
+// Spans
+////////////////////////////////////////////////////////////////////////////
+
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 0 // Work within a service
+	SpanKindServer   SpanKind = 1 // Handling of an incoming request
+	SpanKindClient   SpanKind = 2 // An outgoing request
+)
+
+// One timed operation within a trace.  Once Finish() has been called it is
+// passed to the SpanExporter and should no longer be modified.
+type Span struct {
+	Name         string
+	Kind         SpanKind
+	Context      TraceContext // This span's trace and span ID
+	ParentSpanID [8]byte      // All zeros for the root of a trace
+	Start        time.Time
+	End          time.Time
+	Attributes   map[string]string
+	Err          error
+
+	mu       sync.Mutex
+	finished bool
+	exporter SpanExporter
+}
+
+// Start a new Span as a child of the trace context in 'ctx', or as the root
+// of a new (sampled) trace if there is none.  The returned context carries
+// the new span, so anything started or requested with it becomes its child.
+// Finish() must be called when the operation is done.
+//
+//  ctx, span := base.StartSpan(ctx, "discover-bmc")
+//  defer span.Finish()
+func StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	return startSpan(ctx, name, SpanKindInternal)
+}
+
+func startSpan(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	span := &Span{
+		Name:       name,
+		Kind:       kind,
+		Start:      time.Now(),
+		Attributes: map[string]string{},
+		exporter:   GetSpanExporter(),
+	}
+	if parent, ok := GetTraceContext(ctx); ok && parent.IsValid() {
+		span.Context = parent
+		span.ParentSpanID = parent.SpanID
+	} else {
+		rand.Read(span.Context.TraceID[:])
+		span.Context.Flags = TraceFlagSampled
+	}
+	rand.Read(span.Context.SpanID[:])
+
+	ctx = ContextWithTraceContext(ctx, span.Context)
+	return context.WithValue(ctx, spanContextKey, span), span
+}
+
+// Set attribute 'key' on the span.
+func (s *Span) SetAttribute(key, val string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Attributes[key] = val
+}
+
+// Record that the operation failed with 'err'.
+func (s *Span) SetError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Err = err
+}
+
+// End the span and, if its trace is sampled, export it.  Only the first
+// call has any effect.
+func (s *Span) Finish() {
+	s.mu.Lock()
+	if s.finished {
+		s.mu.Unlock()
+		return
+	}
+	s.finished = true
+	s.End = time.Now()
+	s.mu.Unlock()
+
+	if s.Context.IsSampled() && s.exporter != nil {
+		s.exporter.ExportSpan(s)
+	}
+}
+
+// Duration of a finished span.
+func (s *Span) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+////////////////////////////////////////////////////////////////////////////
+// Exporters
+////////////////////////////////////////////////////////////////////////////
+
+// A SpanExporter sends finished spans to a tracing backend.  ExportSpan()
+// is called from whichever goroutine finishes the span, so it should not
+// block for long.
+type SpanExporter interface {
+	ExportSpan(span *Span)
+}
+
+// Drops all spans.  This is the default exporter.
+type NoopSpanExporter struct{}
+
+func (NoopSpanExporter) ExportSpan(*Span) {}
+
+// Keeps finished spans in memory, mostly for tests.
+type InMemorySpanExporter struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+func NewInMemorySpanExporter() *InMemorySpanExporter {
+	return new(InMemorySpanExporter)
+}
+
+func (e *InMemorySpanExporter) ExportSpan(span *Span) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+// Returns the spans exported so far, in the order they finished.
+func (e *InMemorySpanExporter) Spans() []*Span {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]*Span{}, e.spans...)
+}
+
+// Forget all spans exported so far.
+func (e *InMemorySpanExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = nil
+}
+
+var spanExporter SpanExporter = NoopSpanExporter{}
+var spanExporterLock sync.RWMutex
+
+// Set the exporter for all spans started from now on.  nil restores the
+// default NoopSpanExporter.
+func SetSpanExporter(e SpanExporter) {
+	if e == nil {
+		e = NoopSpanExporter{}
+	}
+	spanExporterLock.Lock()
+	defer spanExporterLock.Unlock()
+	spanExporter = e
+}
+
+// Returns the exporter set with SetSpanExporter().
+func GetSpanExporter() SpanExporter {
+	spanExporterLock.RLock()
+	defer spanExporterLock.RUnlock()
+	return spanExporter
+}
+
+////////////////////////////////////////////////////////////////////////////
+// Server middleware
+////////////////////////////////////////////////////////////////////////////
+
+// Continue the trace from the incoming traceparent/tracestate headers (or
+// start a new one) with a server Span covering the handling of the request.
+// Handlers can add to it via SpanFromContext(r.Context()), and HTTPRequests
+// made with r.Context() become its children.
+func TraceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if tc, ok := ExtractTraceContext(r.Header); ok {
+			ctx = ContextWithTraceContext(ctx, tc)
+		}
+		ctx, span := startSpan(ctx, r.Method+" "+r.URL.Path, SpanKindServer)
+		span.SetAttribute("http.method", r.Method)
+		span.SetAttribute("http.target", r.URL.RequestURI())
+		if id := GetRequestID(ctx); id != "" {
+			span.SetAttribute("request_id", id)
+		}
+
+		rec := recordResponse(w)
+		defer func() {
+			status := rec.status
+			if !rec.wroteHeader {
+				status = http.StatusOK
+			}
+			span.SetAttribute("http.status_code", strconv.Itoa(status))
+			span.Finish()
+		}()
+		next.ServeHTTP(rec, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,221 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package base
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		traceparent string
+		valid       bool
+		sampled     bool
+	}{
+		// Example from the spec
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true, false},
+		// Future versions may have more fields
+		{"cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-what-the-future-will-be-like", true, true},
+		{"cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, true},
+		{"cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01x", false, false},
+		// ...but version 00 may not
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false, false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false, false},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false, false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", false, false},
+		{"00_4bf92f3577b34da6a3ce929d0e0e4736_00f067aa0ba902b7_01", false, false},
+		{"", false, false},
+	}
+	for i, test := range tests {
+		tc, err := ParseTraceparent(test.traceparent)
+		if test.valid != (err == nil) {
+			t.Errorf("Test %d: expected valid: %t, got error: %v", i, test.valid, err)
+			continue
+		}
+		if !test.valid {
+			continue
+		}
+		if tc.IsSampled() != test.sampled {
+			t.Errorf("Test %d: expected sampled: %t", i, test.sampled)
+		}
+		if tc.TraceIDString() != "4bf92f3577b34da6a3ce929d0e0e4736" ||
+			tc.SpanIDString() != "00f067aa0ba902b7" {
+			t.Errorf("Test %d: wrong IDs: %s %s", i, tc.TraceIDString(), tc.SpanIDString())
+		}
+		// We always produce version 00.
+		if tc.Traceparent() != "00"+test.traceparent[2:55] {
+			t.Errorf("Test %d: round trip produced '%s'", i, tc.Traceparent())
+		}
+	}
+}
+
+func TestExtractInjectTraceContext(t *testing.T) {
+	h := http.Header{}
+	if _, ok := ExtractTraceContext(h); ok {
+		t.Errorf("Extracted trace context from empty headers")
+	}
+	h.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.Add(TracestateHeader, "congo=t61rcWkgMzE")
+	h.Add(TracestateHeader, "rojo=00f067aa0ba902b7")
+	tc, ok := ExtractTraceContext(h)
+	if !ok {
+		t.Fatalf("Failed to extract trace context")
+	}
+	if tc.State != "congo=t61rcWkgMzE,rojo=00f067aa0ba902b7" {
+		t.Errorf("Wrong tracestate: '%s'", tc.State)
+	}
+
+	out := http.Header{}
+	out.Set(TracestateHeader, "stale=1")
+	InjectTraceContext(out, tc)
+	if out.Get(TraceparentHeader) != h.Get(TraceparentHeader) ||
+		out.Get(TracestateHeader) != tc.State {
+		t.Errorf("Injected wrong headers: %v", out)
+	}
+	tc.State = ""
+	InjectTraceContext(out, tc)
+	if out.Get(TracestateHeader) != "" {
+		t.Errorf("Stale tracestate not removed: %v", out)
+	}
+}
+
+func TestSpans(t *testing.T) {
+	exporter := NewInMemorySpanExporter()
+	SetSpanExporter(exporter)
+	defer SetSpanExporter(nil)
+
+	ctx, root := StartSpan(context.Background(), "root")
+	if !root.Context.IsValid() || !root.Context.IsSampled() ||
+		root.ParentSpanID != [8]byte{} {
+		t.Errorf("Bad root span context: %+v", root.Context)
+	}
+	if SpanFromContext(ctx) != root {
+		t.Errorf("Context doesn't carry root span")
+	}
+	_, child := StartSpan(ctx, "child")
+	if child.Context.TraceID != root.Context.TraceID ||
+		child.ParentSpanID != root.Context.SpanID ||
+		child.Context.SpanID == root.Context.SpanID {
+		t.Errorf("Child span not linked to root: %+v / %+v", child, root)
+	}
+	child.Finish()
+	child.Finish()
+	root.Finish()
+
+	spans := exporter.Spans()
+	if len(spans) != 2 || spans[0] != child || spans[1] != root {
+		t.Errorf("Expected child and root spans exported once each, got %v", spans)
+	}
+
+	// Unsampled traces aren't exported.
+	exporter.Reset()
+	tc := root.Context
+	tc.Flags = 0
+	_, span := StartSpan(ContextWithTraceContext(context.Background(), tc), "unsampled")
+	span.Finish()
+	if len(exporter.Spans()) != 0 {
+		t.Errorf("Unsampled span was exported")
+	}
+}
+
+func TestTracePropagation(t *testing.T) {
+	exporter := NewInMemorySpanExporter()
+	SetSpanExporter(exporter)
+	defer SetSpanExporter(nil)
+
+	// Downstream service, just reports what it received.
+	var gotParent, gotState string
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotParent = r.Header.Get(TraceparentHeader)
+		gotState = r.Header.Get(TracestateHeader)
+	}))
+	defer downstream.Close()
+
+	// Our service, calls downstream while handling a request.
+	var serverSpan *Span
+	upstream := httptest.NewServer(TraceMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serverSpan = SpanFromContext(r.Context())
+		request := NewHTTPRequest(downstream.URL + "/next")
+		request.Context = r.Context()
+		if _, err := request.DoHTTPAction(); err != nil {
+			t.Errorf("Downstream request failed: %v", err)
+		}
+	})))
+	defer upstream.Close()
+
+	request := NewHTTPRequest(upstream.URL + "/first")
+	ctx := ContextWithTraceContext(context.Background(), TraceContext{
+		TraceID: [16]byte{1, 2, 3},
+		SpanID:  [8]byte{4, 5, 6},
+		Flags:   TraceFlagSampled,
+		State:   "hms=1",
+	})
+	request.Context = ctx
+	if _, err := request.DoHTTPAction(); err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+
+	// Spans: downstream client span, server span, our client span.
+	spans := exporter.Spans()
+	if len(spans) != 3 {
+		t.Fatalf("Expected 3 spans, got %d", len(spans))
+	}
+	downClient, server, firstClient := spans[0], spans[1], spans[2]
+	if server != serverSpan || server.Kind != SpanKindServer ||
+		downClient.Kind != SpanKindClient || firstClient.Kind != SpanKindClient {
+		t.Errorf("Unexpected spans: %+v", spans)
+	}
+	for _, span := range spans {
+		if span.Context.TraceID != [16]byte{1, 2, 3} {
+			t.Errorf("Span '%s' not in original trace", span.Name)
+		}
+	}
+	if firstClient.ParentSpanID != [8]byte{4, 5, 6} ||
+		server.ParentSpanID != firstClient.Context.SpanID ||
+		downClient.ParentSpanID != server.Context.SpanID {
+		t.Errorf("Spans not properly parented")
+	}
+	if gotParent != downClient.Context.Traceparent() || gotState != "hms=1" {
+		t.Errorf("Downstream got traceparent '%s' tracestate '%s'", gotParent, gotState)
+	}
+	if server.Attributes["http.status_code"] != "200" ||
+		downClient.Attributes["http.status_code"] != "200" {
+		t.Errorf("Status codes not recorded: %v %v", server.Attributes, downClient.Attributes)
+	}
+
+	// No trace in the context, nothing is added.
+	exporter.Reset()
+	request = NewHTTPRequest(downstream.URL)
+	if _, err := request.DoHTTPAction(); err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if gotParent != "" || len(exporter.Spans()) != 0 {
+		t.Errorf("Untraced request sent traceparent '%s' or exported spans", gotParent)
+	}
+}
@@ -0,0 +1,220 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package base
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestUnixSocketTransport(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "sidecar.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("Can't listen on a Unix socket: %v", err)
+	}
+	var closed atomic.Int32
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host + " " + r.URL.Path))
+	}), ConnState: func(_ net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			closed.Add(1)
+		}
+	}}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	c := NewHTTPClient()
+	if err := c.SetTransportOptions(TransportOptions{
+		UnixSockets: map[string]string{"sidecar": sock},
+	}); err != nil {
+		t.Fatalf("SetTransportOptions() failed: %v", err)
+	}
+	request := NewHTTPRequest(UnixSocketScheme + "://sidecar/v1/status")
+	request.Client = c
+	body, err := request.DoHTTPAction()
+	if err != nil {
+		t.Fatalf("Request over Unix socket failed: %v", err)
+	}
+	if string(body) != "sidecar /v1/status" {
+		t.Errorf("Expected 'sidecar /v1/status', got '%s'", body)
+	}
+
+	// New TLS settings keep the Unix socket transport, and new options
+	// close its idle connections.
+	unix := c.unix
+	c.SetTLSConfig(&tls.Config{})
+	if c.unix != unix {
+		t.Errorf("SetTLSConfig() replaced the Unix socket transport")
+	}
+	if err := c.SetTransportOptions(TransportOptions{}); err != nil {
+		t.Fatalf("SetTransportOptions() failed: %v", err)
+	}
+	for i := 0; i < 100 && closed.Load() == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if closed.Load() != 1 || c.unix != nil {
+		t.Errorf("Expected idle Unix socket connection closed, %d closed", closed.Load())
+	}
+
+	req := httptest.NewRequest("GET", UnixSocketScheme+"://unknown/v1/status", nil)
+	req.RequestURI = ""
+	_, err = newUnixTransport(map[string]string{"sidecar": sock}).RoundTrip(req)
+	if err == nil || !strings.Contains(err.Error(), "no Unix socket registered for 'unknown'") {
+		t.Errorf("Expected an error for an unregistered socket, got: %v", err)
+	}
+}
+
+func TestProxyTransport(t *testing.T) {
+	var proxied, direct int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&proxied, 1)
+		expect := "Basic " + base64.StdEncoding.EncodeToString([]byte("user:s3cret"))
+		if r.Header.Get("Proxy-Authorization") != expect {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		w.Write([]byte("via proxy to " + r.URL.String()))
+	}))
+	defer proxy.Close()
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&direct, 1)
+		w.Write([]byte("direct"))
+	}))
+	defer backend.Close()
+
+	c := NewHTTPClient()
+	err := c.SetTransportOptions(TransportOptions{
+		Proxy:   strings.Replace(proxy.URL, "http://", "http://user:s3cret@", 1),
+		NoProxy: []string{"127.0.0.0/8", ".internal"},
+	})
+	if err != nil {
+		t.Fatalf("SetTransportOptions() failed: %v", err)
+	}
+
+	request := NewHTTPRequest("http://api.example.com/v1")
+	request.Client = c
+	body, err := request.DoHTTPAction()
+	if err != nil || string(body) != "via proxy to http://api.example.com/v1" {
+		t.Errorf("Expected request via proxy, got '%s', %v", body, err)
+	}
+	request = NewHTTPRequest(backend.URL)
+	request.Client = c
+	body, err = request.DoHTTPAction()
+	if err != nil || string(body) != "direct" {
+		t.Errorf("Expected direct request, got '%s', %v", body, err)
+	}
+	if proxied != 1 || direct != 1 {
+		t.Errorf("Expected 1 proxied and 1 direct request, got %d and %d", proxied, direct)
+	}
+}
+
+func TestNoProxyMatching(t *testing.T) {
+	tests := []struct {
+		entry, host, port string
+		match             bool
+	}{
+		{"*", "anything", "80", true},
+		{"example.com", "example.com", "443", true},
+		{"example.com", "api.example.com", "443", true},
+		{"example.com", "badexample.com", "443", false},
+		{".example.com", "example.com", "443", false},
+		{".example.com", "api.example.com", "443", true},
+		{"10.0.0.0/8", "10.1.2.3", "80", true},
+		{"10.0.0.0/8", "11.1.2.3", "80", false},
+		{"10.1.2.3", "10.1.2.3", "80", true},
+		{"example.com:8080", "example.com", "8080", true},
+		{"example.com:8080", "example.com", "443", false},
+		{"[::1]:443", "::1", "443", true},
+	}
+	for _, tt := range tests {
+		m, err := parseNoProxy(tt.entry)
+		if err != nil {
+			t.Errorf("parseNoProxy(%s) failed: %v", tt.entry, err)
+			continue
+		}
+		if m.matches(tt.host, tt.port) != tt.match {
+			t.Errorf("Expected '%s' matching %s:%s to be %t", tt.entry, tt.host, tt.port, tt.match)
+		}
+	}
+
+	c := NewHTTPClient()
+	bad := []TransportOptions{
+		{Proxy: "ftp://proxy:21"},
+		{Proxy: "http://user:pa55@"},
+		{Proxy: "http://proxy:3128", NoProxy: []string{"."}},
+	}
+	for _, opts := range bad {
+		err := c.SetTransportOptions(opts)
+		if err == nil {
+			t.Errorf("Expected an error for %+v, got none", opts)
+		} else if strings.Contains(err.Error(), "pa55") {
+			t.Errorf("Error leaked proxy password: %v", err)
+		}
+	}
+}
+
+func TestHostOverrides(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host))
+	}))
+	defer srv.Close()
+
+	var dials int32
+	var dialer net.Dialer
+	c := NewHTTPClient()
+	c.SetTLSConfig(&tls.Config{RootCAs: srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs})
+	err := c.SetTransportOptions(TransportOptions{
+		// The test server's certificate is for example.com.
+		HostOverrides: map[string]string{"example.com": srv.Listener.Addr().String()},
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			atomic.AddInt32(&dials, 1)
+			return dialer.DialContext(ctx, network, addr)
+		},
+	})
+	if err != nil {
+		t.Fatalf("SetTransportOptions() failed: %v", err)
+	}
+	if c.TLSConfig() == nil {
+		t.Errorf("SetTransportOptions() lost the TLS configuration")
+	}
+
+	request := NewHTTPRequest("https://example.com/")
+	request.Client = c
+	body, err := request.DoHTTPAction()
+	if err != nil {
+		t.Fatalf("Request with host override failed: %v", err)
+	}
+	if string(body) != "example.com" || dials != 1 {
+		t.Errorf("Expected Host 'example.com' and 1 dial, got '%s' and %d", body, dials)
+	}
+}
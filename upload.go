@@ -0,0 +1,331 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package base
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// A request body that can be read more than once, so requests carrying it
+// can be retried.  Open is called for each attempt and must return a
+// reader positioned at the start of the body; if it is also an io.Closer
+// it is closed once the attempt is over.  Length is the size of the body
+// in bytes, or -1 if it isn't known up front (in which case it is sent
+// chunked).
+//
+// Set it as an HTTPRequest's Body instead of Payload to stream a large body
+// from disk rather than hold it in memory:
+//
+//  body, err := base.NewFileBody("/images/bios.bin")
+//  ...
+//  request.Method = http.MethodPut
+//  request.ContentType = "application/octet-stream"
+//  request.Body = body
+type RequestBody struct {
+	Open   func() (io.Reader, error)
+	Length int64
+
+	exclusive bool // Readers share state, so only one request at a time
+}
+
+// A RequestBody holding 'data'.
+func NewBytesBody(data []byte) *RequestBody {
+	return &RequestBody{
+		Open:   func() (io.Reader, error) { return bytes.NewReader(data), nil },
+		Length: int64(len(data)),
+	}
+}
+
+// A RequestBody read from the file at 'path', which is opened afresh for
+// each attempt.  The file should not change while in use.
+func NewFileBody(path string) (*RequestBody, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to use %s as request body: %w", path, err)
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("unable to use %s as request body: not a regular file", path)
+	}
+	return &RequestBody{
+		Open:   func() (io.Reader, error) { return os.Open(path) },
+		Length: info.Size(),
+	}, nil
+}
+
+// A RequestBody read from 'r', which is rewound to where it is now for
+// each attempt.  Since the reader is shared, requests using the body must
+// not be made concurrently (so it can't be used with FanOutHTTP()).
+func NewReadSeekerBody(r io.ReadSeeker) (*RequestBody, error) {
+	start, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("unable to use reader as request body: %w", err)
+	}
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, fmt.Errorf("unable to use reader as request body: %w", err)
+	}
+	return &RequestBody{
+		Open: func() (io.Reader, error) {
+			if _, err := r.Seek(start, io.SeekStart); err != nil {
+				return nil, err
+			}
+			return io.NopCloser(r), nil
+		},
+		Length:    end - start,
+		exclusive: true,
+	}, nil
+}
+
+// Reports upload progress of a request body: bytes sent so far, and the
+// total (-1 if unknown).  Starts over from 0 if the request is retried.
+type ProgressFunc func(sent, total int64)
+
+// Wraps the reader of one attempt at sending a body, reporting progress.
+type progressReader struct {
+	r     io.Reader
+	sent  int64
+	total int64
+	fn    ProgressFunc
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.sent += int64(n)
+		p.fn(p.sent, p.total)
+	}
+	return n, err
+}
+
+func (p *progressReader) Close() error {
+	if c, ok := p.r.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Returns the function retryablehttp uses to get the body for each attempt.
+func (body *RequestBody) readerFunc(progress ProgressFunc) func() (io.Reader, error) {
+	return func() (io.Reader, error) {
+		r, err := body.Open()
+		if err != nil || progress == nil {
+			return r, err
+		}
+		return &progressReader{r: r, total: body.Length, fn: progress}, nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////
+// multipart/form-data
+////////////////////////////////////////////////////////////////////////////
+
+// Builds a multipart/form-data request body, e.g. for a Redfish
+// MultipartHttpPushUri firmware update.  Nothing is read until the body is
+// sent, and files are streamed from disk, so arbitrarily large files can
+// be uploaded.
+//
+//  mp := base.NewMultipartBuilder()
+//  mp.AddJSON("UpdateParameters", map[string]interface{}{
+//      "Targets": []string{"/redfish/v1/UpdateService/FirmwareInventory/BIOS"},
+//  })
+//  if err := mp.AddFile("UpdateFile", "/images/bios.bin"); err != nil {
+//      ...
+//  }
+//  request.Method = http.MethodPost
+//  request.ContentType = mp.ContentType()
+//  request.Body = mp.Body()
+type MultipartBuilder struct {
+	boundary string
+	parts    []multipartPart
+}
+
+type multipartPart struct {
+	header textproto.MIMEHeader
+	body   *RequestBody
+}
+
+// Create an empty MultipartBuilder with a random boundary.
+func NewMultipartBuilder() *MultipartBuilder {
+	return &MultipartBuilder{boundary: multipart.NewWriter(io.Discard).Boundary()}
+}
+
+// The Content-Type of the body, including its boundary.
+func (m *MultipartBuilder) ContentType() string {
+	return "multipart/form-data; boundary=" + m.boundary
+}
+
+// Add a plain form field.
+func (m *MultipartBuilder) AddField(name, value string) {
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"`, escapeQuotes(name)))
+	m.parts = append(m.parts, multipartPart{header: h, body: NewBytesBody([]byte(value))})
+}
+
+// Add a field with 'v' marshaled to JSON, with Content-Type
+// application/json.
+func (m *MultipartBuilder) AddJSON(name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("unable to marshal multipart field %s: %w", name, err)
+	}
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"`, escapeQuotes(name)))
+	h.Set("Content-Type", "application/json")
+	m.parts = append(m.parts, multipartPart{header: h, body: NewBytesBody(data)})
+	return nil
+}
+
+// Add the file at 'path' as field 'name', with its base name as filename
+// and Content-Type application/octet-stream.
+func (m *MultipartBuilder) AddFile(name, path string) error {
+	body, err := NewFileBody(path)
+	if err != nil {
+		return err
+	}
+	m.AddFileBody(name, filepath.Base(path), "application/octet-stream", body)
+	return nil
+}
+
+// Add a file part read from 'body'.
+func (m *MultipartBuilder) AddFileBody(name, filename, contentType string, body *RequestBody) {
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		escapeQuotes(name), escapeQuotes(filename)))
+	h.Set("Content-Type", contentType)
+	m.parts = append(m.parts, multipartPart{header: h, body: body})
+}
+
+// The body with all parts added so far.  Its Length is known as long as
+// that of every part is.  If any part is from NewReadSeekerBody(), the body
+// has the same restrictions.
+func (m *MultipartBuilder) Body() *RequestBody {
+	// Render the part headers and boundaries up front; the part bodies go
+	// in between.
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	mw.SetBoundary(m.boundary)
+
+	headers := make([][]byte, len(m.parts))
+	length := int64(0)
+	exclusive := false
+	for i, part := range m.parts {
+		exclusive = exclusive || part.body.exclusive
+		mw.CreatePart(part.header)
+		headers[i] = append([]byte{}, buf.Bytes()...)
+		buf.Reset()
+		if length >= 0 && part.body.Length >= 0 {
+			length += int64(len(headers[i])) + part.body.Length
+		} else {
+			length = -1
+		}
+	}
+	mw.Close()
+	trailer := append([]byte{}, buf.Bytes()...)
+	if length >= 0 {
+		length += int64(len(trailer))
+	}
+
+	parts := m.parts
+	return &RequestBody{
+		Open: func() (io.Reader, error) {
+			return &multipartReader{parts: parts, headers: headers, trailer: trailer}, nil
+		},
+		Length:    length,
+		exclusive: exclusive,
+	}
+}
+
+// Reads a multipart body, opening each part's body only when it is
+// reached.
+type multipartReader struct {
+	parts   []multipartPart
+	headers [][]byte
+	trailer []byte
+	next    int
+	cur     io.Reader
+}
+
+func (r *multipartReader) Read(p []byte) (int, error) {
+	for {
+		if r.cur != nil {
+			n, err := r.cur.Read(p)
+			if err != io.EOF {
+				return n, err
+			}
+			r.closeCurrent()
+			if n > 0 {
+				return n, nil
+			}
+		}
+		if r.next > len(r.parts) {
+			return 0, io.EOF
+		}
+		if r.next == len(r.parts) {
+			r.cur = bytes.NewReader(r.trailer)
+			r.next++
+			continue
+		}
+		part := r.parts[r.next]
+		body, err := part.body.Open()
+		if err != nil {
+			return 0, fmt.Errorf("unable to open multipart body: %w", err)
+		}
+		r.cur = io.MultiReader(bytes.NewReader(r.headers[r.next]), body)
+		if c, ok := body.(io.Closer); ok {
+			r.cur = &readCloser{Reader: r.cur, Closer: c}
+		}
+		r.next++
+	}
+}
+
+func (r *multipartReader) closeCurrent() {
+	if c, ok := r.cur.(io.Closer); ok {
+		c.Close()
+	}
+	r.cur = nil
+}
+
+// Close whichever part body is open, if any.
+func (r *multipartReader) Close() error {
+	r.closeCurrent()
+	return nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
@@ -0,0 +1,211 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package base
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestUploadFileBodyRetry(t *testing.T) {
+	data := bytes.Repeat([]byte("firmware"), 64*1024)
+	file := filepath.Join(t.TempDir(), "image.bin")
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		if !bytes.Equal(got, data) || r.ContentLength != int64(len(data)) {
+			t.Errorf("Attempt %d: got %d bytes, Content-Length %d", attempts, len(got), r.ContentLength)
+		}
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	body, err := NewFileBody(file)
+	if err != nil {
+		t.Fatalf("Unable to create file body: %v", err)
+	}
+	var last, total int64
+	request := NewHTTPRequest(srv.URL)
+	request.Method = http.MethodPut
+	request.ContentType = "application/octet-stream"
+	request.Body = body
+	request.OnProgress = func(sent, tot int64) {
+		last, total = sent, tot
+	}
+	if _, err := request.DoHTTPAction(); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+	if last != int64(len(data)) || total != int64(len(data)) {
+		t.Errorf("Unexpected final progress %d/%d", last, total)
+	}
+
+	if _, err := NewFileBody(filepath.Dir(file)); err == nil {
+		t.Errorf("Expected error for directory as body")
+	}
+}
+
+func TestUploadReadSeekerBody(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got = append(got, string(b))
+		if len(got) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	r := strings.NewReader("skipped|sent")
+	r.Seek(8, io.SeekStart)
+	body, err := NewReadSeekerBody(r)
+	if err != nil {
+		t.Fatalf("Unable to create body: %v", err)
+	}
+	if body.Length != 4 {
+		t.Errorf("Expected length 4, got %d", body.Length)
+	}
+	request := NewHTTPRequest(srv.URL)
+	request.Method = http.MethodPost
+	request.Body = body
+	if _, err := request.DoHTTPAction(); err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if len(got) != 2 || got[0] != "sent" || got[1] != "sent" {
+		t.Errorf("Unexpected bodies received: %q", got)
+	}
+
+	request.Payload = []byte("too")
+	if _, err := request.DoHTTPAction(); err == nil {
+		t.Errorf("Expected error with both Payload and Body")
+	}
+}
+
+func TestUploadUnknownLength(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		if string(b) != "streamed" || r.ContentLength != -1 {
+			t.Errorf("Unexpected body %q, Content-Length %d", b, r.ContentLength)
+		}
+	}))
+	defer srv.Close()
+
+	request := NewHTTPRequest(srv.URL)
+	request.Method = http.MethodPost
+	request.Body = &RequestBody{
+		Open:   func() (io.Reader, error) { return io.MultiReader(strings.NewReader("stream"), strings.NewReader("ed")), nil },
+		Length: -1,
+	}
+	if _, err := request.DoHTTPAction(); err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+}
+
+func TestMultipartBuilder(t *testing.T) {
+	image := bytes.Repeat([]byte{0, 1, 2, 3}, 10000)
+	file := filepath.Join(t.TempDir(), `bios "v2".bin`)
+	if err := os.WriteFile(file, image, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1024); err != nil {
+			t.Errorf("Unable to parse multipart body: %v", err)
+			return
+		}
+		w.Header().Set("X-Length", strconv.FormatInt(r.ContentLength, 10))
+		if v := r.FormValue("Operation"); v != "Update" {
+			t.Errorf("Unexpected Operation field %q", v)
+		}
+		if v := r.FormValue("UpdateParameters"); v != `{"Targets":["/redfish/v1/Systems/1"]}` {
+			t.Errorf("Unexpected UpdateParameters field %q", v)
+		}
+		f, hdr, err := r.FormFile("UpdateFile")
+		if err != nil {
+			t.Errorf("No UpdateFile: %v", err)
+			return
+		}
+		defer f.Close()
+		got, _ := io.ReadAll(f)
+		if !bytes.Equal(got, image) || hdr.Filename != `bios "v2".bin` ||
+			hdr.Header.Get("Content-Type") != "application/octet-stream" {
+			t.Errorf("Unexpected file part %v (%d bytes)", hdr.Header, len(got))
+		}
+	}))
+	defer srv.Close()
+
+	mp := NewMultipartBuilder()
+	mp.AddField("Operation", "Update")
+	if err := mp.AddJSON("UpdateParameters", map[string][]string{"Targets": {"/redfish/v1/Systems/1"}}); err != nil {
+		t.Fatalf("AddJSON failed: %v", err)
+	}
+	if err := mp.AddFile("UpdateFile", file); err != nil {
+		t.Fatalf("AddFile failed: %v", err)
+	}
+	if err := mp.AddFile("Missing", file+".nope"); err == nil {
+		t.Errorf("Expected error adding missing file")
+	}
+	body := mp.Body()
+
+	// The body reads the same each time, and is as long as it says.
+	first, _ := body.Open()
+	b1, _ := io.ReadAll(first)
+	second, _ := body.Open()
+	b2, _ := io.ReadAll(second)
+	if !bytes.Equal(b1, b2) || int64(len(b1)) != body.Length {
+		t.Errorf("Body not repeatable or wrong length: %d, %d, %d", len(b1), len(b2), body.Length)
+	}
+
+	request := NewHTTPRequest(srv.URL)
+	request.Method = http.MethodPost
+	request.ContentType = mp.ContentType()
+	request.Body = body
+	resp, err := request.DoHTTPResponse()
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.Header.Get("X-Length") != strconv.FormatInt(body.Length, 10) {
+		t.Errorf("Expected Content-Length %d, got %s", body.Length, resp.Header.Get("X-Length"))
+	}
+}
@@ -0,0 +1,231 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package base
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Identifies the service making HTTP requests, for the User-Agent header
+// of every request it makes, so the services it calls can tell who is
+// calling (see GetCallerIdentity()).  It is rendered as an RFC 9110
+// product with a comment, e.g.
+//
+//  cray-smd/2.3.0 (instance=cray-smd-5d8f7c9b4-x2x7q; component=discovery)
+type ServiceIdentity struct {
+	Service   string // Service name, e.g. "cray-smd"
+	Version   string // Service version, if known
+	Instance  string // Which replica, e.g. from GetServiceInstanceName()
+	Component string // Part of the service making requests, if useful
+}
+
+// Create a ServiceIdentity for version 'version' of 'service', with the
+// Instance set from GetServiceInstanceName().
+func NewServiceIdentity(service, version string) *ServiceIdentity {
+	inst, _ := GetServiceInstanceName()
+	return &ServiceIdentity{Service: service, Version: version, Instance: inst}
+}
+
+// Returns a copy of id with Component set to 'component'.
+func (id *ServiceIdentity) WithComponent(component string) *ServiceIdentity {
+	cp := *id
+	cp.Component = component
+	return &cp
+}
+
+// Render id as a User-Agent header value.  Characters not allowed in a
+// product token are replaced with '-', and any in the comment escaped.
+func (id *ServiceIdentity) UserAgent() string {
+	service := productToken(id.Service)
+	if service == "" {
+		service = "unknown"
+	}
+	ua := service
+	if v := productToken(id.Version); v != "" {
+		ua += "/" + v
+	}
+	var attrs []string
+	if id.Instance != "" {
+		attrs = append(attrs, "instance="+commentText(id.Instance))
+	}
+	if id.Component != "" {
+		attrs = append(attrs, "component="+commentText(id.Component))
+	}
+	if len(attrs) > 0 {
+		ua += " (" + strings.Join(attrs, "; ") + ")"
+	}
+	return ua
+}
+
+func (id *ServiceIdentity) String() string {
+	return id.UserAgent()
+}
+
+// Is 'c' an RFC 9110 tchar?
+func isTokenChar(c byte) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
+}
+
+func productToken(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if !isTokenChar(c) {
+			b[i] = '-'
+		}
+	}
+	return string(b)
+}
+
+func commentText(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '(' || c == ')' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c == ';':
+			b.WriteByte(',')
+		case c < ' ' || c == 0x7f:
+			b.WriteByte(' ')
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// Parse the first product (and the comment following it, if any) of a
+// User-Agent header value into a ServiceIdentity.  Instance and Component
+// are only set if the comment has the "instance=" and "component=" entries
+// written by ServiceIdentity.UserAgent().  Returns an error if 'ua' does
+// not start with a product token.
+func ParseUserAgent(ua string) (*ServiceIdentity, error) {
+	ua = strings.TrimLeft(ua, " \t")
+	end := 0
+	for end < len(ua) && (isTokenChar(ua[end]) || ua[end] == '/') {
+		end++
+	}
+	product := ua[:end]
+	service, version, _ := strings.Cut(product, "/")
+	if service == "" || strings.Contains(version, "/") {
+		return nil, fmt.Errorf("invalid User-Agent product '%s'", product)
+	}
+	id := &ServiceIdentity{Service: service, Version: version}
+
+	rest := strings.TrimLeft(ua[end:], " \t")
+	if !strings.HasPrefix(rest, "(") {
+		return id, nil
+	}
+	comment, ok := parseComment(rest)
+	if !ok {
+		return id, nil
+	}
+	for _, attr := range strings.Split(comment, ";") {
+		key, val, _ := strings.Cut(strings.TrimSpace(attr), "=")
+		switch key {
+		case "instance":
+			id.Instance = val
+		case "component":
+			id.Component = val
+		}
+	}
+	return id, nil
+}
+
+// Returns the unescaped text of the comment at the start of 's' (which
+// starts with '('), and false if it is not terminated.
+func parseComment(s string) (string, bool) {
+	var b strings.Builder
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s):
+			i++
+			b.WriteByte(s[i])
+			continue
+		case c == '(':
+			depth++
+			if depth == 1 {
+				continue
+			}
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return b.String(), true
+			}
+		}
+		b.WriteByte(c)
+	}
+	return "", false
+}
+
+var defaultServiceIdentity *ServiceIdentity
+var defaultServiceIdentityLock sync.RWMutex
+
+// Returns the ServiceIdentity used by HTTPClients that have none of their
+// own.  Unless set with SetDefaultServiceIdentity(), it is made up from the
+// name of the executable and GetServiceInstanceName().
+func GetDefaultServiceIdentity() *ServiceIdentity {
+	defaultServiceIdentityLock.RLock()
+	id := defaultServiceIdentity
+	defaultServiceIdentityLock.RUnlock()
+	if id != nil {
+		return id
+	}
+
+	defaultServiceIdentityLock.Lock()
+	defer defaultServiceIdentityLock.Unlock()
+	if defaultServiceIdentity == nil {
+		defaultServiceIdentity = NewServiceIdentity(filepath.Base(os.Args[0]), "")
+	}
+	return defaultServiceIdentity
+}
+
+// Set the ServiceIdentity used by HTTPClients that have none of their own,
+// normally once, early in main().  A nil 'id' restores the made up one.
+func SetDefaultServiceIdentity(id *ServiceIdentity) {
+	defaultServiceIdentityLock.Lock()
+	defer defaultServiceIdentityLock.Unlock()
+	defaultServiceIdentity = id
+}
+
+// Returns the identity of the calling service, parsed from the User-Agent
+// captured by UserAgentMiddleware, and false if there is no (parsable)
+// User-Agent.
+func GetCallerIdentity(ctx context.Context) (*ServiceIdentity, bool) {
+	id, err := ParseUserAgent(GetUserAgent(ctx))
+	if err != nil {
+		return nil, false
+	}
+	return id, true
+}
@@ -0,0 +1,117 @@
+// MIT License
+//
+// (C) Copyright [2026] Hewlett Packard Enterprise Development LP
+//
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
+
+package base
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestServiceIdentityUserAgent(t *testing.T) {
+	tests := []struct {
+		id     ServiceIdentity
+		expect string
+	}{
+		{ServiceIdentity{Service: "cray-smd", Version: "2.3.0"}, "cray-smd/2.3.0"},
+		{ServiceIdentity{Service: "cray-smd", Version: "2.3.0", Instance: "smd-1", Component: "discovery"},
+			"cray-smd/2.3.0 (instance=smd-1; component=discovery)"},
+		{ServiceIdentity{Service: "my service", Instance: "a(b);c"},
+			`my-service (instance=a\(b\),c)`},
+		{ServiceIdentity{}, "unknown"},
+	}
+	for _, tt := range tests {
+		if ua := tt.id.UserAgent(); ua != tt.expect {
+			t.Errorf("Expected User-Agent '%s', got '%s'", tt.expect, ua)
+		}
+	}
+}
+
+func TestParseUserAgent(t *testing.T) {
+	in := ServiceIdentity{Service: "cray-hbtd", Version: "1.2.3-rc1",
+		Instance: "hbtd-(x)", Component: "heartbeats"}
+	id, err := ParseUserAgent(in.UserAgent() + " Go-http-client/1.1")
+	if err != nil {
+		t.Fatalf("ParseUserAgent() failed: %v", err)
+	}
+	if *id != in {
+		t.Errorf("Expected %+v, got %+v", in, *id)
+	}
+
+	id, err = ParseUserAgent("Mozilla/5.0 (X11; Linux x86_64)")
+	if err != nil || id.Service != "Mozilla" || id.Version != "5.0" || id.Instance != "" {
+		t.Errorf("Unexpected result for browser User-Agent: %+v, %v", id, err)
+	}
+	for _, ua := range []string{"", "(comment only)", "/1.0", "a/b/c"} {
+		if _, err := ParseUserAgent(ua); err == nil {
+			t.Errorf("Expected an error parsing '%s', got none", ua)
+		}
+	}
+}
+
+func TestServiceIdentityApplied(t *testing.T) {
+	var got []string
+	var caller *ServiceIdentity
+	srv := httptest.NewServer(UserAgentMiddleware(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			got = r.Header[USERAGENT]
+			caller, _ = GetCallerIdentity(r.Context())
+		})))
+	defer srv.Close()
+
+	client := NewHTTPClient()
+	id := NewServiceIdentity("cray-smd", "2.3.0").WithComponent("discovery")
+	client.SetServiceIdentity(id)
+	request := NewHTTPRequest(srv.URL)
+	request.Client = client
+	if _, err := request.DoHTTPAction(); err != nil {
+		t.Fatalf("DoHTTPAction() failed: %v", err)
+	}
+	if len(got) != 1 || got[0] != id.UserAgent() {
+		t.Errorf("Expected User-Agent '%s', got %v", id.UserAgent(), got)
+	}
+	if caller == nil || *caller != *id {
+		t.Errorf("Expected caller %+v, got %+v", id, caller)
+	}
+
+	// The caller's own User-Agent wins.
+	request.Header = http.Header{USERAGENT: {"custom/1.0"}}
+	if _, err := request.DoHTTPAction(); err != nil {
+		t.Fatalf("DoHTTPAction() failed: %v", err)
+	}
+	if len(got) != 1 || got[0] != "custom/1.0" {
+		t.Errorf("Expected User-Agent 'custom/1.0', got %v", got)
+	}
+
+	// Without one of its own, a client uses the default.
+	SetDefaultServiceIdentity(&ServiceIdentity{Service: "default-svc"})
+	defer SetDefaultServiceIdentity(nil)
+	request = NewHTTPRequest(srv.URL)
+	request.Client = NewHTTPClient()
+	if _, err := request.DoHTTPAction(); err != nil {
+		t.Fatalf("DoHTTPAction() failed: %v", err)
+	}
+	if len(got) != 1 || got[0] != "default-svc" {
+		t.Errorf("Expected User-Agent 'default-svc', got %v", got)
+	}
+}
@@ -1,6 +1,6 @@
 // MIT License
 //
-// (C) Copyright [2018, 2021] Hewlett Packard Enterprise Development LP
+// (C) Copyright [2018, 2021, 2026] Hewlett Packard Enterprise Development LP
 //
 // Permission is hereby granted, free of charge, to any person obtaining a
 // copy of this software and associated documentation files (the "Software"),
@@ -143,16 +143,17 @@ func (p *WorkerPool) Run() {
 
 // Hands out jobs to available workers
 func (p *WorkerPool) dispatch() {
+dispatchLoop:
 	for {
 		// Wait for a job or a stop signal
 		select {
 		case <-p.StopChannel:
-			break
+			break dispatchLoop
 		case job := <-p.JobQueue:
 			// Wait for a free worker or a stop signal
 			select {
 			case <-p.StopChannel:
-				break
+				break dispatchLoop
 			case jobChannel := <-p.Pool:
 				if status, _ := job.GetStatus(); status != JSTAT_CANCELLED {
 					job.SetStatus(JSTAT_PROCESSING, nil)